@@ -23,7 +23,9 @@ func Init() {
 		Sequence: args.Sequence,
 		Type:     args.Type,
 		Target:   args.Target,
-		Input:    "",
+		Input:    args.Input,
+		Output:   args.Output,
+		Template: args.Template,
 		Pwd:      args.Pwd + "/",
 	}
 
@@ -40,6 +42,9 @@ func Init() {
 		config.Init()
 		// build all template contents
 		app.BuildProject()
+	case "convert":
+		// convert a single markdown file or stdin without a project
+		app.ConvertFile()
 	case "move":
 		// change sequence of given page
 	case "delete":
@@ -81,6 +86,15 @@ func parseArgs() types.Args {
 	var target string
 	flag.StringVar(&target, "target", "./", "-target /target/directory/to/build/into")
 
+	var input string
+	flag.StringVar(&input, "in", "", "-in file.md (default: stdin)")
+
+	var output string
+	flag.StringVar(&output, "out", "", "-out file.html (default: stdout)")
+
+	var template string
+	flag.StringVar(&template, "template", "", "-template main.html")
+
 	// parse the flags
 	flag.Parse()
 
@@ -96,7 +110,9 @@ func parseArgs() types.Args {
 		Sequence: sequence,
 		Type:     ctype,
 		Target:   target,
-		Input:    "",
+		Input:    input,
+		Output:   output,
+		Template: template,
 		Pwd:      wdir,
 	}
 
@@ -106,7 +122,7 @@ func printHelpText() {
     helpText := `gomcmf — static content/site builder
 
 Usage:
-  gomcmf -command <init|create|build> [flags]
+  gomcmf -command <init|create|build|convert> [flags]
   gomcmf help
 
 Commands:
@@ -126,6 +142,15 @@ Commands:
     Flags:
       -target string        Target directory to build into (default: ./)
 
+  convert                  Convert a single markdown file or stdin to HTML
+                           without a project. The converter settings of a
+                           config.json in the working directory are used.
+    Flags:
+      -in string            Markdown file to convert (default: stdin)
+      -out string           File to write the HTML to (default: stdout)
+      -template string      Template to wrap the HTML in, the content is
+                           inserted at {{render:content}}
+
 Global flags:
   -verbose                 Enable verbose logging
   -help                    Show flag help generated by Go's flag package
@@ -138,6 +163,8 @@ Examples:
   gomcmf -command init
   gomcmf -command create -name "My First Post" -type md
   gomcmf -command build -target ./public
+  gomcmf -command convert -in README.md -out readme.html
+  cat notes.md | gomcmf -command convert -template main.html
 
 Config (config.json) keys used during build:
   base            Base URL for links (e.g., "/" or "https://example.com/")
@@ -148,6 +175,28 @@ Config (config.json) keys used during build:
   mainFile        Main HTML template file (e.g., "main.html")
   indexFile       Index markdown file (e.g., "index.md")
   404File         Not‑Found markdown file (e.g., "404.md")
+
+Optional config keys:
+  mathRenderer    Math output: "none" (default), "client" (\(...\) spans) or "mathml"
+  blockRenderers  Per-language code block wrappers or commands (see README)
+  shortcodesPath  Directory with shortcode snippets (default: shortcodes)
+  partialsPath    Directory with {{include:...}} partials (default: partials)
+  dataPath        Directory with json, yaml and csv data files (default: data)
+  templateEngine  Engine for mainFile and layouts: "markers" (default) or "go"
+  smartTypography Curly quotes, dashes and ellipses (default: false)
+  typographyLocale Quote style for smartTypography [en|de|ch|fr|pl|sv]
+  autolinkRel     rel attribute for autolinked urls (e.g., "noopener")
+  autolinkTarget  target attribute for autolinked urls (e.g., "_blank")
+  linkPolicy      rel/target/class rules for external links (see README)
+  brokenLinks     Links to missing page files: "warn" (default), "error", "ignore"
+  outputFormats   Additional page formats, e.g. ["gmi", "txt"]
+  textWidth       Line width of the txt output (default: 72)
+  excerptWords    Word limit of page excerpts (default: 0, whole paragraph)
+  wordsPerMinute  Reading speed for {{page:readingtime}} (default: 200)
+  pagerAcrossDirectories Prev/next links cross directories (default: false)
+  dateFormat      Default date format: "date" (default), "datetime", "long", "short", "rfc3339", "rfc1123" or a go layout
+  dateLocale      Month and weekday names of dates [en|de|fr|es|it|nl]
+  timezone        Timezone dates are shown in, e.g. "Europe/Berlin" (default: local)
 `
     loggerOut.Println(helpText)
 }
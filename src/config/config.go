@@ -7,13 +7,24 @@ import (
 )
 
 var Data = make(map[string]string)
+var Objects = make(map[string]json.RawMessage)
 var requiredConfigs = [100]string{"base", "indexFile", "404File", "mainFile", "pagesPath", "resourcesPath", "title", "buildPath", "verbose"}
+var optionalConfigs = []string{"mathRenderer", "blockRenderers", "shortcodesPath", "smartTypography", "typographyLocale", "autolinkRel", "autolinkTarget", "linkPolicy", "brokenLinks", "outputFormats", "textWidth", "excerptWords", "pagerAcrossDirectories", "partialsPath", "templateEngine", "dateFormat", "dateLocale", "timezone", "wordsPerMinute", "dataPath"}
 
 func Init() {
 	// first lets check if there is a parseable config file
 	handleConfigFile()
 }
 
+// InitOptional loads the config file if there is one, for commands which
+// work without a project
+func InitOptional() {
+	if _, err := os.Stat("config.json"); nil != err {
+		return
+	}
+	handleConfigFile()
+}
+
 func GetValue(key string) string {
 	val, exist := Data[key]
 	if !exist {
@@ -23,6 +34,41 @@ func GetValue(key string) string {
 	return val
 }
 
+func GetValueOrDefault(key string, defaultValue string) string {
+	val, exist := Data[key]
+	if !exist || "" == val {
+		return defaultValue
+	}
+	return val
+}
+
+// GetBool returns a config flag given either as json bool or as string
+func GetBool(key string, defaultValue bool) bool {
+	if val, exist := Data[key]; exist {
+		return "true" == val || "1" == val || "yes" == val
+	}
+	var val bool
+	if raw, exist := Objects[key]; exist && nil == json.Unmarshal(raw, &val) {
+		return val
+	}
+	return defaultValue
+}
+
+// GetObject decodes a structured (non string) config value into target
+// and reports whether the config was given at all
+func GetObject(key string, target interface{}) bool {
+	raw, exist := Objects[key]
+	if !exist {
+		return false
+	}
+	err := json.Unmarshal(raw, target)
+	if nil != err {
+		fmt.Printf("> Config %s has an invalid structure: %s", key, err.Error())
+		os.Exit(0)
+	}
+	return true
+}
+
 func SetValue(key string, value string) {
 	Data[key] = value
 }
@@ -37,7 +83,7 @@ func handleConfigFile() {
     }
 	// now we parse the config contents
 	// lets see if the body json is valid tho
-	Conf := make(map[string]string)
+	Conf := make(map[string]json.RawMessage)
 	err = json.Unmarshal(data, &Conf)
 	if nil != err {
 		fmt.Print("> Config file content is not a valid json")
@@ -49,7 +95,26 @@ func handleConfigFile() {
 	for _, name := range requiredConfigs {
 		value, ok := Conf[name]
 		if ok {
-			Data[name] = value
+			setRawValue(name, value)
 		}
 	}
+
+	// optional configs may be omitted, the caller provides a default
+	for _, name := range optionalConfigs {
+		value, ok := Conf[name]
+		if ok {
+			setRawValue(name, value)
+		}
+	}
+}
+
+// setRawValue stores string values in Data and everything else
+// (objects, lists, numbers, bools) in Objects
+func setRawValue(name string, value json.RawMessage) {
+	var str string
+	if nil == json.Unmarshal(value, &str) {
+		Data[name] = str
+		return
+	}
+	Objects[name] = value
 }
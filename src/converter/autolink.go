@@ -0,0 +1,131 @@
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+const angleAutolinkRxp = `<((?:https?|ftp)://[^\s<>]+|mailto:[^\s<>]+|[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9-]+(?:\.[a-zA-Z0-9-]+)+)>`
+const bareUrlRxp = `(?i)\b(?:https?://|www\.)[^\s<]+`
+const bareEmailRxp = `\b[a-zA-Z0-9._+-]+@[a-zA-Z0-9-]+(?:\.[a-zA-Z0-9-]+)*\.[a-zA-Z]{2,}\b`
+
+// parseAngleAutolinks converts <https://...> and <user@host> autolinks.
+// It has to run before all other inline rules since they would take the
+// angle brackets for a html tag.
+func parseAngleAutolinks(content *Content, text string) string {
+	tmp := regexp.MustCompile(angleAutolinkRxp)
+	return tmp.ReplaceAllStringFunc(text, func(match string) string {
+		target := tmp.FindStringSubmatch(match)[1]
+		if strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+			return content.Protect(content.buildAutolink(target, strings.TrimPrefix(target, "mailto:")))
+		}
+		return content.Protect(content.buildAutolink("mailto:"+target, target))
+	})
+}
+
+// parseBareAutolinks converts urls starting with http(s):// or www. and
+// email addresses written as plain text. Text inside tags and inside of
+// already existing anchors is left untouched.
+func parseBareAutolinks(content *Content, text string) string {
+	urlRxp := regexp.MustCompile(bareUrlRxp)
+	emailRxp := regexp.MustCompile(bareEmailRxp)
+	return applyOutsideElements(text, []string{"a", "code", "pre"}, func(s string) string {
+		s = urlRxp.ReplaceAllStringFunc(s, func(match string) string {
+			url, trailing := trimAutolinkTrailing(match)
+			href := url
+			if strings.HasPrefix(strings.ToLower(url), "www.") {
+				href = "http://" + url
+			}
+			return content.Protect(content.buildAutolink(href, url)) + trailing
+		})
+		return emailRxp.ReplaceAllStringFunc(s, func(match string) string {
+			return content.Protect(content.buildAutolink("mailto:"+match, match))
+		})
+	})
+}
+
+func (self *Content) buildAutolink(href string, text string) string {
+	attributes := LinkAttributes{}
+	if !strings.HasPrefix(href, "mailto:") {
+		attributes = LinkAttributes{
+			Rel:    strings.Fields(self.Options.AutolinkRel),
+			Target: self.Options.AutolinkTarget,
+		}
+	}
+	attributes = attributes.Merge(self.getLinkAttributes(href))
+	return "<a href='" + escapeHtml(href) + "'" + attributes.String() + ">" + escapeHtml(text) + "</a>"
+}
+
+// trimAutolinkTrailing strips trailing punctuation from a bare url the same
+// way gfm does, closing parentheses are only kept if they are balanced
+func trimAutolinkTrailing(url string) (string, string) {
+	trailing := ""
+	for 0 < len(url) {
+		last := url[len(url)-1]
+		if strings.IndexByte("?!.,:*_~;'\"", last) != -1 {
+			trailing = string(last) + trailing
+			url = url[:len(url)-1]
+		} else if ')' == last && strings.Count(url, ")") > strings.Count(url, "(") {
+			trailing = string(last) + trailing
+			url = url[:len(url)-1]
+		} else {
+			break
+		}
+	}
+	return url, trailing
+}
+
+// applyOutsideElements works like applyOutsideTags but additionally skips
+// the text content of the given elements, for example the text of an anchor
+func applyOutsideElements(s string, elements []string, transform func(string) string) string {
+	var out strings.Builder
+	var seg strings.Builder
+	skipElement := ""
+	skipDepth := 0
+
+	for i := 0; i < len(s); i++ {
+		if '<' != s[i] {
+			if 0 < skipDepth {
+				out.WriteByte(s[i])
+			} else {
+				seg.WriteByte(s[i])
+			}
+			continue
+		}
+		end := strings.IndexByte(s[i:], '>')
+		if -1 == end {
+			end = len(s) - 1
+		} else {
+			end += i
+		}
+		// flush preceding text segment with transform
+		if 0 < seg.Len() {
+			out.WriteString(transform(seg.String()))
+			seg.Reset()
+		}
+		tag := s[i : end+1]
+		name := getTagName(tag)
+		if 0 == skipDepth {
+			for _, element := range elements {
+				if element == name && !strings.HasPrefix(tag, "</") && !strings.HasSuffix(tag, "/>") {
+					skipElement = name
+					skipDepth = 1
+				}
+			}
+		} else if name == skipElement {
+			if strings.HasPrefix(tag, "</") {
+				skipDepth--
+			} else if !strings.HasSuffix(tag, "/>") {
+				skipDepth++
+			}
+		}
+		out.WriteString(tag)
+		i = end
+	}
+
+	// flush any remaining text segment
+	if 0 < seg.Len() {
+		out.WriteString(transform(seg.String()))
+	}
+	return out.String()
+}
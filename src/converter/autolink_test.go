@@ -0,0 +1,56 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertAutolinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		md      string
+		want    string
+	}{
+		{"angle url", Options{}, "see <https://example.com/a_b_c>", "see <a href='https://example.com/a_b_c'>https://example.com/a_b_c</a>"},
+		{"angle email", Options{}, "mail <me@example.com>", "mail <a href='mailto:me@example.com'>me@example.com</a>"},
+		{"bare url", Options{}, "see https://example.com.", "see <a href='https://example.com'>https://example.com</a>."},
+		{"bare www gets a scheme", Options{}, "see www.example.com", "see <a href='http://www.example.com'>www.example.com</a>"},
+		{"balanced parentheses", Options{}, "(see https://x.org/a_(b))", "(see <a href='https://x.org/a_(b)'>https://x.org/a_(b)</a>)"},
+		{"bare email", Options{}, "write me@example.com", "write <a href='mailto:me@example.com'>me@example.com</a>"},
+		{"existing link untouched", Options{}, "[https://x.org](https://x.org)", "<a href='https://x.org'>https://x.org</a>"},
+		{"inline code untouched", Options{}, "`https://x.org`", "<code>https://x.org</code>"},
+		{"rel and target", Options{AutolinkRel: "nofollow", AutolinkTarget: "_blank"}, "https://x.org", "<a href='https://x.org' rel='nofollow' target='_blank'>https://x.org</a>"},
+		{"no target for mail", Options{AutolinkTarget: "_blank"}, "me@example.com", "<a href='mailto:me@example.com'>me@example.com</a>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := Content{Md: test.md, Options: test.options}
+			content.Convert()
+			if !strings.Contains(content.Html, test.want) {
+				t.Errorf("expected %q in %q", test.want, content.Html)
+			}
+		})
+	}
+}
+
+func TestTrimAutolinkTrailing(t *testing.T) {
+	tests := []struct {
+		url      string
+		want     string
+		trailing string
+	}{
+		{"https://x.org", "https://x.org", ""},
+		{"https://x.org/?", "https://x.org/", "?"},
+		{"https://x.org).", "https://x.org", ")."},
+		{"https://x.org/(a)", "https://x.org/(a)", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			got, trailing := trimAutolinkTrailing(test.url)
+			if test.want != got || test.trailing != trailing {
+				t.Errorf("expected %q, %q but got %q, %q", test.want, test.trailing, got, trailing)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// BlockRenderer renders the content of a fenced code block tagged with a
+// specific language, for example ```mermaid, into html
+type BlockRenderer interface {
+	Render(lang string, code string) (string, error)
+}
+
+// WrapBlockRenderer wraps the code block content into custom html, for
+// example <pre class="mermaid"> ... </pre>. The content is html escaped
+// unless Raw is set.
+type WrapBlockRenderer struct {
+	Open  string
+	Close string
+	Raw   bool
+}
+
+// CommandBlockRenderer pipes the code block content into a local command
+// and inlines its stdout, for example an svg generated by 'dot -Tsvg'
+type CommandBlockRenderer struct {
+	Command string
+	Args    []string
+	Open    string
+	Close   string
+}
+
+var blockRenderers = make(map[string]BlockRenderer)
+
+func RegisterBlockRenderer(lang string, renderer BlockRenderer) {
+	blockRenderers[lang] = renderer
+}
+
+func GetBlockRenderer(lang string) (BlockRenderer, bool) {
+	if "" == lang {
+		return nil, false
+	}
+	renderer, ok := blockRenderers[lang]
+	return renderer, ok
+}
+
+func (self WrapBlockRenderer) Render(lang string, code string) (string, error) {
+	if !self.Raw {
+		code = escapeHtml(code)
+	}
+	return self.Open + code + self.Close, nil
+}
+
+func (self CommandBlockRenderer) Render(lang string, code string) (string, error) {
+	if "" == self.Command {
+		return "", errors.New("no command configured")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(self.Command, self.Args...)
+	cmd.Stdin = strings.NewReader(code)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if nil != err {
+		return "", errors.New(err.Error() + " " + strings.TrimSpace(stderr.String()))
+	}
+	return self.Open + strings.TrimSpace(stdout.String()) + self.Close, nil
+}
@@ -0,0 +1,102 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+func TestWrapBlockRenderer(t *testing.T) {
+	tests := []struct {
+		name     string
+		renderer WrapBlockRenderer
+		want     string
+	}{
+		{"escaped", WrapBlockRenderer{Open: "<pre class='mermaid'>", Close: "</pre>"}, "<pre class='mermaid'>a --&gt; b</pre>"},
+		{"raw", WrapBlockRenderer{Open: "<div>", Close: "</div>", Raw: true}, "<div>a --> b</div>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.renderer.Render("mermaid", "a --> b")
+			if nil != err {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestCommandBlockRenderer(t *testing.T) {
+	tests := []struct {
+		name     string
+		renderer CommandBlockRenderer
+		want     string
+		wantErr  bool
+	}{
+		{"stdout is inlined", CommandBlockRenderer{Command: "tr", Args: []string{"a-z", "A-Z"}, Open: "<div>", Close: "</div>"}, "<div>A -> B</div>", false},
+		{"missing command", CommandBlockRenderer{}, "", true},
+		{"failing command", CommandBlockRenderer{Command: "false"}, "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.renderer.Render("dot", "a -> b")
+			if test.wantErr {
+				if nil == err {
+					t.Errorf("expected an error but got %q", got)
+				}
+				return
+			}
+			if nil != err {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestConvertCodeBlockRenderer(t *testing.T) {
+	RegisterBlockRenderer("mermaid", WrapBlockRenderer{Open: "<pre class='mermaid'>", Close: "</pre>"})
+	defer delete(blockRenderers, "mermaid")
+
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{"registered language", "```mermaid\na --> b\n```", "<pre class='mermaid'>a --&gt; b</pre>"},
+		{"other language", "```go\nx := 1\n```", "<pre><code class='language-go'>\nx := 1\n    </code></pre>"},
+		{"unterminated block", "```mermaid\na --> b", "<pre class='mermaid'>a --&gt; b</pre>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := Content{Md: test.md}
+			content.Convert()
+			if !strings.Contains(content.Html, test.want) {
+				t.Errorf("expected %q in %q", test.want, content.Html)
+			}
+		})
+	}
+}
+
+func TestConvertFailingCodeBlockRenderer(t *testing.T) {
+	RegisterBlockRenderer("dot", CommandBlockRenderer{Command: "false"})
+	defer delete(blockRenderers, "dot")
+	defer util.ResetReportedErrors()
+	util.ResetReportedErrors()
+
+	content := Content{Md: "# Graph\n\n```dot\na -> b\n```", Options: Options{File: "page.md", LineOffset: 3}}
+	content.Convert()
+	want := "<pre><code class='language-dot'>\na -&gt; b\n    </code></pre>"
+	if !strings.Contains(content.Html, want) {
+		t.Errorf("expected %q in %q", want, content.Html)
+	}
+	reported := util.GetReportedErrors()
+	if 1 != len(reported) || "page.md" != reported[0].File || 6 != reported[0].Line {
+		t.Errorf("expected one error at page.md:6 but got %v", reported)
+	}
+}
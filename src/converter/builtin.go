@@ -0,0 +1,246 @@
+package converter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+const headingsRxp = `(#+)\s?(.+)`
+const imageRxp1 = `!\[([^\]]*)\]\(([^\]]*)\s"(.*)"\)`
+const imageRxp2 = `!\[([^\]]*)\]\(([^\]]*)\)`
+const videoRxp = `!?\[video\]\((.+\.mp4)\)`
+const linkRxp1 = `\[([^\]]+)\]\(([^\]]*)\s"(.*)"(?:\s(_blank))?\)`
+const linkRxp2 = `\[([^\]]+)\]\(([^\]\s]*)(?:\s(_blank))?\)`
+const boldRxp1 = `\*\*(.+?)\*\*`
+const boldRxp2 = `__(.+?)__`
+const italicRxp1 = `\*(.+?)\*`
+const italicRxp2 = `_(.+?)_`
+const unorderedListItemRxp = `-\s?(.+)`
+const blockquoteRxp = `>\s?(.*)`
+const codeblockRxp = "```(.*)"
+const inlineCodeRxp = "`([^`]+)`"
+
+// priorities of the built-in parsers, custom parsers can be sorted in
+// between them
+const PriorityHeading = 500
+const PriorityListing = 400
+const PriorityBlockQuote = 300
+const PriorityCodeBlock = 200
+const PriorityMathBlock = 100
+
+const PriorityInlineCode = 1000
+const PriorityInlineMath = 900
+const PriorityAngleAutolinks = 800
+const PriorityVideos = 700
+const PriorityImages = 600
+const PriorityLinks = 500
+const PriorityBareAutolinks = 400
+const PriorityBolds = 300
+const PriorityItalics = 200
+const PriorityTypography = 100
+
+func init() {
+	RegisterBlockParser(NewBlockParser("heading", PriorityHeading, openHeading))
+	RegisterBlockParser(NewBlockParser("listing", PriorityListing, openListing))
+	RegisterBlockParser(NewBlockParser("blockquote", PriorityBlockQuote, openBlockQuote))
+	RegisterBlockParser(codeBlockParser{})
+	RegisterBlockParser(mathBlockParser{})
+
+	RegisterInlineParser(NewInlineParser("code", PriorityInlineCode, parseInlineCode))
+	RegisterInlineParser(NewInlineParser("math", PriorityInlineMath, parseInlineMath))
+	RegisterInlineParser(NewInlineParser("angleAutolinks", PriorityAngleAutolinks, parseAngleAutolinks))
+	RegisterInlineParser(NewInlineParser("videos", PriorityVideos, parseVideos))
+	RegisterInlineParser(NewInlineParser("images", PriorityImages, parseImages))
+	RegisterInlineParser(NewInlineParser("links", PriorityLinks, parseLinks))
+	RegisterInlineParser(NewInlineParser("bareAutolinks", PriorityBareAutolinks, parseBareAutolinks))
+	RegisterInlineParser(NewInlineParser("bolds", PriorityBolds, parseBolds))
+	RegisterInlineParser(NewInlineParser("italics", PriorityItalics, parseItalics))
+	RegisterInlineParser(NewInlineParser("typography", PriorityTypography, parseTypography))
+}
+
+func openHeading(content *Content) bool {
+	if !strings.HasPrefix(content.State.CurrentLineString, "#") {
+		return false
+	}
+	rxp := regexp.MustCompile(headingsRxp)
+	match := rxp.FindStringSubmatch(content.State.CurrentLineString)
+	if nil == match {
+		return false
+	}
+	content.CloseParagraph()
+	level := strconv.Itoa(len(match[1]))
+	content.State.CurrentLineString = "\n  <h" + level + ">" + content.ParseInline(match[2]) + "</h" + level + ">"
+	return true
+}
+
+func openListing(content *Content) bool {
+	if !strings.HasPrefix(content.State.CurrentLineString, "- ") {
+		return false
+	}
+	content.OpenWrap("ul", "- ")
+	rxp := regexp.MustCompile(unorderedListItemRxp)
+	match := rxp.FindStringSubmatch(content.State.CurrentLineString)
+	content.State.CurrentLineString = "\n      <li>" + content.ParseInline(match[1]) + "</li>"
+	return true
+}
+
+func openBlockQuote(content *Content) bool {
+	if !strings.HasPrefix(content.State.CurrentLineString, "> ") {
+		return false
+	}
+	content.OpenWrap("blockquote", "> ")
+	rxp := regexp.MustCompile(blockquoteRxp)
+	match := rxp.FindStringSubmatch(content.State.CurrentLineString)
+	content.State.CurrentLineString = "\n" + content.ParseInline(match[1])
+	return true
+}
+
+// codeBlockParser handles fenced code blocks. Languages with a registered
+// BlockRenderer are rendered by it, all others end up in <pre><code>.
+type codeBlockParser struct{}
+
+func (self codeBlockParser) Name() string {
+	return "codeblock"
+}
+
+func (self codeBlockParser) Priority() int {
+	return PriorityCodeBlock
+}
+
+func (self codeBlockParser) Open(content *Content) bool {
+	if !strings.HasPrefix(content.State.CurrentLineString, "```") {
+		return false
+	}
+	content.CloseParagraph()
+	tmp := regexp.MustCompile(codeblockRxp)
+	content.State.BlockInfo = strings.TrimSpace(tmp.FindStringSubmatch(content.State.CurrentLineString)[1])
+	content.State.BlockLines = nil
+	content.State.CurrentLineString = ""
+	content.StartBlock(self)
+	return true
+}
+
+func (self codeBlockParser) Continue(content *Content) bool {
+	if "```" == content.State.CurrentLineString {
+		self.Close(content)
+		return false
+	}
+	content.State.BlockLines = append(content.State.BlockLines, content.State.CurrentLineString)
+	return true
+}
+
+func (self codeBlockParser) Close(content *Content) {
+	lang := content.State.BlockInfo
+	code := strings.Join(content.State.BlockLines, "\n")
+	content.State.BlockLines = nil
+
+	// languages with a registered renderer are rendered by it
+	renderer, ok := GetBlockRenderer(lang)
+	if ok {
+		html, err := renderer.Render(lang, code)
+		if nil == err {
+			content.Html = content.Html + "\n    " + html + "\n"
+			return
+		}
+		// the block is reported and kept as plain code
+		util.ReportError(util.SourceError{
+			File:    content.Options.File,
+			Line:    content.Options.LineOffset + content.State.BlockStartLine + 1,
+			Column:  1,
+			Message: "Rendering '" + lang + "' code block failed with error '" + err.Error() + "'",
+		})
+	}
+
+	content.Html = content.Html + "\n    <pre><code class='language-" + lang + "'>"
+	if "" != code {
+		content.Html = content.Html + "\n" + escapeHtml(code)
+	}
+	content.Html = content.Html + "\n    </code></pre>\n"
+}
+
+// parseInlineCode renders `code` escaped and protected, so neither math nor
+// emphasis is applied to its content
+func parseInlineCode(content *Content, text string) string {
+	if !strings.Contains(text, "`") {
+		return text
+	}
+	tmp := regexp.MustCompile(inlineCodeRxp)
+	return tmp.ReplaceAllStringFunc(text, func(match string) string {
+		return content.Protect("<code>" + escapeHtml(tmp.FindStringSubmatch(match)[1]) + "</code>")
+	})
+}
+
+func parseVideos(content *Content, text string) string {
+	tmp := regexp.MustCompile(videoRxp)
+	return tmp.ReplaceAllString(text, "<video width='100%' height='auto' controls><source src='$1' type='video/mp4'>Your browser does not support the video tag.</video>")
+}
+
+func parseImages(content *Content, text string) string {
+	tmp := regexp.MustCompile(imageRxp1)
+	text = tmp.ReplaceAllString(text, "      <img src='$2' alt='$1' title='$3'/>")
+	tmp = regexp.MustCompile(imageRxp2)
+	return tmp.ReplaceAllString(text, "      <img src='$2' alt='$1'/>")
+}
+
+func parseLinks(content *Content, text string) string {
+	tmp := regexp.MustCompile(linkRxp1)
+	text = tmp.ReplaceAllStringFunc(text, func(match string) string {
+		submatch := tmp.FindStringSubmatch(match)
+		text := submatch[1]
+		url := content.resolveLink(submatch[2])
+		title := submatch[3]
+		attributes := LinkAttributes{}
+		if len(submatch) > 4 && submatch[4] == "_blank" {
+			attributes.Target = "_blank"
+		}
+		attributes = attributes.Merge(content.getLinkAttributes(url))
+		return "<a href='" + url + "' title='" + title + "'" + attributes.String() + ">" + text + "</a>"
+	})
+
+	tmp = regexp.MustCompile(linkRxp2)
+	return tmp.ReplaceAllStringFunc(text, func(match string) string {
+		submatch := tmp.FindStringSubmatch(match)
+		text := submatch[1]
+		url := content.resolveLink(submatch[2])
+		attributes := LinkAttributes{}
+		if len(submatch) > 3 && submatch[3] == "_blank" {
+			attributes.Target = "_blank"
+		}
+		attributes = attributes.Merge(content.getLinkAttributes(url))
+		return "<a href='" + url + "'" + attributes.String() + ">" + text + "</a>"
+	})
+}
+
+func (self *Content) resolveLink(href string) string {
+	if nil == self.Options.ResolveLink {
+		return href
+	}
+	return self.Options.ResolveLink(href)
+}
+
+func parseBolds(content *Content, text string) string {
+	// Apply bold formatting only outside of HTML tags to avoid
+	// corrupting attributes (e.g., underscores in href/src).
+	rx1 := regexp.MustCompile(boldRxp1)
+	rx2 := regexp.MustCompile(boldRxp2)
+	return applyOutsideTags(text, func(s string) string {
+		s = rx1.ReplaceAllString(s, "<b>$1</b>")
+		s = rx2.ReplaceAllString(s, "<b>$1</b>")
+		return s
+	})
+}
+
+func parseItalics(content *Content, text string) string {
+	// Apply italic formatting only outside of HTML tags to avoid
+	// corrupting attributes (e.g., underscores in href/src).
+	rx1 := regexp.MustCompile(italicRxp1)
+	rx2 := regexp.MustCompile(italicRxp2)
+	return applyOutsideTags(text, func(s string) string {
+		s = rx1.ReplaceAllString(s, "<i>$1</i>")
+		s = rx2.ReplaceAllString(s, "<i>$1</i>")
+		return s
+	})
+}
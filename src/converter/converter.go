@@ -1,37 +1,48 @@
 package converter
 
 import (
-	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/voodooEntity/gomcmf/src/types"
 	"github.com/voodooEntity/gomcmf/src/util"
 )
 
-const headingsRxp = `(#+)\s?(.+)`
-const imageRxp1 = `!\[([^\]]*)\]\(([^\]]*)\s"(.*)"\)`
-const imageRxp2 = `!\[([^\]]*)\]\(([^\]]*)\)`
-const videoRxp = `!?\[video\]\((.+\.mp4)\)`
-const linkRxp1 = `\[([^\]]+)\]\(([^\]]*)\s"(.*)"(?:\s(_blank))?\)`
-const linkRxp2 = `\[([^\]]+)\]\(([^\]\s]*)(?:\s(_blank))?\)`
-const boldRxp1 = `\*\*(.+?)\*\*`
-const boldRxp2 = `__(.+?)__`
-const italicRxp1 = `\*(.+?)\*`
-const italicRxp2 = `_(.+?)_`
-const unorderedListItemRxp = `-\s?(.+)`
-const blockquoteRxp = `>\s?(.*)`
-const codeblockRxp = "```(.*)"
+const MathRendererClient = "client"
+const MathRendererMathML = "mathml"
+const MathRendererNone = "none"
+
+var MathRenderers = []string{MathRendererNone, MathRendererClient, MathRendererMathML}
 
 type Content struct {
-	Md    string
-	Html  string
-	State State
+	Md      string
+	Html    string
+	State   State
+	Options Options
+}
+
+type Options struct {
+	MathRenderer     string
+	SmartTypography  bool
+	TypographyLocale string
+	AutolinkRel      string
+	AutolinkTarget   string
+	Base             string
+	LinkPolicy       *types.LinkPolicy
+	// ResolveLink optionally rewrites the href of markdown links
+	ResolveLink func(href string) string
+	// File and LineOffset locate the markdown in its source file for
+	// error reporting
+	File       string
+	LineOffset int
 }
 
 type State struct {
 	IsOpenParagraph   bool
 	IsOpenBlock       bool
-	InCodeBlock       bool
+	ActiveBlock       MultilineBlockParser
+	BlockInfo         string
+	BlockLines        []string
 	InUnorderedList   bool
 	IsOpenWrap        bool
 	WrapHtml          string
@@ -40,6 +51,8 @@ type State struct {
 	CurrentLine       int
 	CurrentLineString string
 	LineSplit         []string
+	Protected         []string
+	BlockStartLine    int
 }
 
 func (self *Content) Set(content string) {
@@ -52,7 +65,6 @@ func (self *Content) Convert() {
 	self.State = State{
 		IsOpenParagraph: false,
 		IsOpenBlock:     true,
-		InCodeBlock:     false,
 		InUnorderedList: false,
 		EmptyLineCnt:    0,
 		LineSplit:       util.Explode("\n", self.Md),
@@ -64,43 +76,93 @@ func (self *Content) Convert() {
 	for curr, val := range splitText {
 		self.State.CurrentLine = curr
 		self.State.CurrentLineString = strings.TrimSuffix(val, "\r")
-		if !self.State.InCodeBlock {
-			if "" == self.State.CurrentLineString {
-				self.State.EmptyLineCnt++
-			} else {
-				// close uls before handling codeblocks, should be handled nicer ###
-				self.closeWrap()
-				self.handleEmptyLines()
-				self.openBlock()
-				isHeading := self.handleHeading()
-				isListing := self.handleListing()
-				isBlockQuote := self.handleBlockQuote()
-				isCodeBlock := self.handleCodeBlockOpen()
-				if !isHeading && !isListing && !isCodeBlock && !isBlockQuote {
-					if !self.openParagraph() {
-						self.Html = self.Html + "<br>"
-					}
-					self.handleSubStringElements()
-				}
-				self.State.EmptyLineCnt = 0
-				self.Html = self.Html + self.State.CurrentLineString
+		if nil != self.State.ActiveBlock {
+			// multi line blocks consume all lines up to their end
+			if !self.State.ActiveBlock.Continue(self) {
+				self.State.ActiveBlock = nil
 			}
+		} else if "" == self.State.CurrentLineString {
+			self.State.EmptyLineCnt++
 		} else {
-			if "```" == self.State.CurrentLineString {
-				self.State.InCodeBlock = false
-				self.Html = self.Html + "\n    </code></pre>\n"
-			} else {
-				self.Html = self.Html + "\n" + self.State.CurrentLineString
+			// close uls before handling codeblocks, should be handled nicer ###
+			self.closeWrap()
+			self.handleEmptyLines()
+			self.openBlock()
+			if !self.handleBlock() {
+				if !self.openParagraph() {
+					self.Html = self.Html + "<br>"
+				}
+				self.State.CurrentLineString = self.ParseInline(self.State.CurrentLineString)
 			}
+			self.State.EmptyLineCnt = 0
+			self.Html = self.Html + self.State.CurrentLineString
 		}
 	}
-	self.closeParagraph()
+	// unterminated multi line blocks are closed at the end of the document
+	if nil != self.State.ActiveBlock {
+		self.State.ActiveBlock.Close(self)
+		self.State.ActiveBlock = nil
+	}
+	self.CloseParagraph()
 	if self.State.IsOpenBlock {
 		self.Html = self.Html + "\n</div>"
 	}
 }
 
-func (self *Content) openWrap(tag string, prefix string) {
+// ConvertInline converts only the inline elements of the markdown, for
+// snippets which are placed within a line of html
+func (self *Content) ConvertInline() {
+	self.State = State{}
+	self.Html = self.ParseInline(self.Md)
+}
+
+// handleBlock offers the current line to all block parsers by priority,
+// the first one claiming it handles the line
+func (self *Content) handleBlock() bool {
+	for _, parser := range GetBlockParsers() {
+		if parser.Open(self) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseInline runs all inline parsers by priority on the given text
+func (self *Content) ParseInline(text string) string {
+	protectedOffset := len(self.State.Protected)
+	for _, parser := range GetInlineParsers() {
+		text = parser.Parse(self, text)
+	}
+	return self.restoreProtected(text, protectedOffset)
+}
+
+// StartBlock routes all following lines to the given parser until its
+// Continue method reports the end of the block
+func (self *Content) StartBlock(parser MultilineBlockParser) {
+	self.State.ActiveBlock = parser
+	self.State.BlockStartLine = self.State.CurrentLine
+}
+
+// Protect stores already rendered html and returns a placeholder for it.
+// The placeholder contains no markdown relevant characters, so the inline
+// parsers leave it untouched until ParseInline puts the html back.
+func (self *Content) Protect(html string) string {
+	self.State.Protected = append(self.State.Protected, html)
+	return "\x02" + strconv.Itoa(len(self.State.Protected)-1) + "\x03"
+}
+
+func (self *Content) restoreProtected(text string, offset int) string {
+	for i := len(self.State.Protected) - 1; i >= offset; i-- {
+		placeholder := "\x02" + strconv.Itoa(i) + "\x03"
+		text = strings.ReplaceAll(text, placeholder, self.State.Protected[i])
+	}
+	self.State.Protected = self.State.Protected[:offset]
+	return text
+}
+
+// OpenWrap opens an element wrapping all following lines starting with
+// prefix, like <ul> for lines starting with '- '
+func (self *Content) OpenWrap(tag string, prefix string) {
 	if !self.State.IsOpenWrap {
 		self.State.WrapLinePrefix = prefix
 		self.State.WrapHtml = tag
@@ -116,7 +178,7 @@ func (self *Content) closeWrap() {
 	}
 }
 
-func (self *Content) closeParagraph() {
+func (self *Content) CloseParagraph() {
 	if self.State.IsOpenParagraph {
 		self.State.IsOpenParagraph = false
 		self.Html = self.Html + "\n  </p>"
@@ -157,133 +219,6 @@ func (self *Content) handleEmptyLines() {
 	self.State.EmptyLineCnt = 0
 }
 
-func (self *Content) handleHeading() bool {
-	if !strings.HasPrefix(self.State.CurrentLineString, "#") {
-		return false
-	}
-	self.closeParagraph()
-	rxp := regexp.MustCompile(headingsRxp)
-	match := rxp.FindStringSubmatch(self.State.CurrentLineString)
-	self.State.CurrentLineString = match[2]
-	self.handleSubStringElements()
-	if self.State.IsOpenParagraph {
-		self.State.IsOpenParagraph = false
-		self.Html = self.Html + "\n  </p>"
-	}
-	self.State.CurrentLineString = "\n  <h" + strconv.Itoa(len(match[1])) + ">" + self.State.CurrentLineString + "</h" + strconv.Itoa(len(match[1])) + ">"
-	return true
-}
-
-func (self *Content) handleSubStringElements() {
-	self.handleVideos()
-	self.handleImages()
-	self.handleLinks()
-	self.handleBolds()
-	self.handleItalics()
-}
-
-func (self *Content) handleVideos() {
-	tmp := regexp.MustCompile(videoRxp)
-	self.State.CurrentLineString = tmp.ReplaceAllString(self.State.CurrentLineString, "<video width='100%' height='auto' controls><source src='$1' type='video/mp4'>Your browser does not support the video tag.</video>")
-}
-
-func (self *Content) handleImages() {
-	tmp := regexp.MustCompile(imageRxp1)
-	self.State.CurrentLineString = tmp.ReplaceAllString(self.State.CurrentLineString, "      <img src='$2' alt='$1' title='$3'/>")
-	tmp = regexp.MustCompile(imageRxp2)
-	self.State.CurrentLineString = tmp.ReplaceAllString(self.State.CurrentLineString, "      <img src='$2' alt='$1'/>")
-}
-
-func (self *Content) handleCodeBlockOpen() bool {
-	if !strings.HasPrefix(self.State.CurrentLineString, "```") {
-		return false
-	}
-	self.closeParagraph()
-	self.State.InCodeBlock = true
-	tmp := regexp.MustCompile(codeblockRxp)
-	self.State.CurrentLineString = tmp.ReplaceAllString(self.State.CurrentLineString, "\n    <pre><code class='language-$1'>")
-	return true
-}
-
-func (self *Content) handleListing() bool {
-	if !strings.HasPrefix(self.State.CurrentLineString, "- ") {
-		return false
-	}
-	//self.openParagraph()
-	self.openWrap("ul", "- ")
-	rxp := regexp.MustCompile(unorderedListItemRxp)
-	match := rxp.FindStringSubmatch(self.State.CurrentLineString)
-	self.State.CurrentLineString = match[1]
-	self.handleSubStringElements()
-	self.State.CurrentLineString = "\n      <li>" + self.State.CurrentLineString + "</li>"
-	return true
-}
-
-func (self *Content) handleBlockQuote() bool {
-	if !strings.HasPrefix(self.State.CurrentLineString, "> ") {
-		return false
-	}
-	//self.openParagraph()
-	self.openWrap("blockquote", "> ")
-	rxp := regexp.MustCompile(blockquoteRxp)
-	match := rxp.FindStringSubmatch(self.State.CurrentLineString)
-	self.State.CurrentLineString = match[1]
-	self.handleSubStringElements()
-	self.State.CurrentLineString = "\n" + self.State.CurrentLineString
-	return true
-}
-
-func (self *Content) handleLinks() {
-	tmp := regexp.MustCompile(linkRxp1)
-	self.State.CurrentLineString = tmp.ReplaceAllStringFunc(self.State.CurrentLineString, func(match string) string {
-		submatch := tmp.FindStringSubmatch(match)
-		text := submatch[1]
-		url := submatch[2]
-		title := submatch[3]
-		target := ""
-		if len(submatch) > 4 && submatch[4] == "_blank" {
-			target = " target='_blank'"
-		}
-		return "<a href='" + url + "' title='" + title + "'" + target + ">" + text + "</a>"
-	})
-
-	tmp = regexp.MustCompile(linkRxp2)
-	self.State.CurrentLineString = tmp.ReplaceAllStringFunc(self.State.CurrentLineString, func(match string) string {
-		submatch := tmp.FindStringSubmatch(match)
-		text := submatch[1]
-		url := submatch[2]
-		target := ""
-		if len(submatch) > 3 && submatch[3] == "_blank" {
-			target = " target='_blank'"
-		}
-		return "<a href='" + url + "'" + target + ">" + text + "</a>"
-	})
-}
-
-func (self *Content) handleBolds() {
-	// Apply bold formatting only outside of HTML tags to avoid
-	// corrupting attributes (e.g., underscores in href/src).
-	rx1 := regexp.MustCompile(boldRxp1)
-	rx2 := regexp.MustCompile(boldRxp2)
-	self.State.CurrentLineString = applyOutsideTags(self.State.CurrentLineString, func(s string) string {
-		s = rx1.ReplaceAllString(s, "<b>$1</b>")
-		s = rx2.ReplaceAllString(s, "<b>$1</b>")
-		return s
-	})
-}
-
-func (self *Content) handleItalics() {
-	// Apply italic formatting only outside of HTML tags to avoid
-	// corrupting attributes (e.g., underscores in href/src).
-	rx1 := regexp.MustCompile(italicRxp1)
-	rx2 := regexp.MustCompile(italicRxp2)
-	self.State.CurrentLineString = applyOutsideTags(self.State.CurrentLineString, func(s string) string {
-		s = rx1.ReplaceAllString(s, "<i>$1</i>")
-		s = rx2.ReplaceAllString(s, "<i>$1</i>")
-		return s
-	})
-}
-
 // applyOutsideTags applies a transformation function only to the portions of
 // the input string that are outside HTML tags (i.e., not between '<' and '>').
 // This prevents inline markdown formatting from altering HTML attributes or
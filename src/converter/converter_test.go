@@ -0,0 +1,38 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertInlineCode(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{"escaped", "use `a < b` here", "use <code>a &lt; b</code> here"},
+		{"no emphasis", "call `do_it_now()` or **this**", "call <code>do_it_now()</code> or <b>this</b>"},
+		{"no math", "price `$5 and $10`", "price <code>$5 and $10</code>"},
+		{"no links", "write `[a](b)`", "write <code>[a](b)</code>"},
+		{"unpaired backtick", "a ` b", "a ` b"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := Content{Md: test.md, Options: Options{MathRenderer: MathRendererClient}}
+			content.Convert()
+			if !strings.Contains(content.Html, test.want) {
+				t.Errorf("expected %q in %q", test.want, content.Html)
+			}
+		})
+	}
+}
+
+func TestConvertInline(t *testing.T) {
+	content := Content{Md: "some **bold** `code`"}
+	content.ConvertInline()
+	want := "some <b>bold</b> <code>code</code>"
+	if want != content.Html {
+		t.Errorf("expected %q but got %q", want, content.Html)
+	}
+}
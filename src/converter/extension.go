@@ -0,0 +1,141 @@
+package converter
+
+import (
+	"sort"
+)
+
+// BlockParser handles lines opening a block like headings or list items.
+// Open is called for every non empty line outside of multi line blocks,
+// with the line in content.State.CurrentLineString. A parser claiming the
+// line returns true and replaces CurrentLineString with the html to append.
+type BlockParser interface {
+	Name() string
+	Priority() int
+	Open(content *Content) bool
+}
+
+// MultilineBlockParser is a BlockParser whose blocks span several lines,
+// like fenced code blocks. Once Open called content.StartBlock(parser) all
+// following lines are passed to Continue until it returns false. Close is
+// called if the document ends while the block is still open. Per document
+// state belongs into content.State.BlockInfo and content.State.BlockLines.
+type MultilineBlockParser interface {
+	BlockParser
+	Continue(content *Content) bool
+	Close(content *Content)
+}
+
+// InlineParser transforms the text of a paragraph line, heading, list item
+// or blockquote. The parsers run in order of their priority, each one
+// receiving the output of the previous one. Html which must not be touched
+// by the following parsers should be wrapped with content.Protect.
+type InlineParser interface {
+	Name() string
+	Priority() int
+	Parse(content *Content, text string) string
+}
+
+var blockParsers []BlockParser
+var inlineParsers []InlineParser
+
+// RegisterBlockParser adds a block parser. Parsers with a higher priority
+// are asked first, a parser with the name of an already registered one
+// replaces it.
+func RegisterBlockParser(parser BlockParser) {
+	for i, registered := range blockParsers {
+		if registered.Name() == parser.Name() {
+			blockParsers = append(blockParsers[:i], blockParsers[i+1:]...)
+			break
+		}
+	}
+	blockParsers = append(blockParsers, parser)
+	sort.SliceStable(blockParsers, func(i, j int) bool {
+		return blockParsers[i].Priority() > blockParsers[j].Priority()
+	})
+}
+
+// RegisterInlineParser adds an inline parser. Parsers with a higher
+// priority run first, a parser with the name of an already registered one
+// replaces it.
+func RegisterInlineParser(parser InlineParser) {
+	for i, registered := range inlineParsers {
+		if registered.Name() == parser.Name() {
+			inlineParsers = append(inlineParsers[:i], inlineParsers[i+1:]...)
+			break
+		}
+	}
+	inlineParsers = append(inlineParsers, parser)
+	sort.SliceStable(inlineParsers, func(i, j int) bool {
+		return inlineParsers[i].Priority() > inlineParsers[j].Priority()
+	})
+}
+
+// UnregisterParser removes the block and inline parsers with the given name
+func UnregisterParser(name string) {
+	for i, registered := range blockParsers {
+		if registered.Name() == name {
+			blockParsers = append(blockParsers[:i], blockParsers[i+1:]...)
+			break
+		}
+	}
+	for i, registered := range inlineParsers {
+		if registered.Name() == name {
+			inlineParsers = append(inlineParsers[:i], inlineParsers[i+1:]...)
+			break
+		}
+	}
+}
+
+func GetBlockParsers() []BlockParser {
+	return blockParsers
+}
+
+func GetInlineParsers() []InlineParser {
+	return inlineParsers
+}
+
+// NewBlockParser creates a single line BlockParser from a function
+func NewBlockParser(name string, priority int, open func(content *Content) bool) BlockParser {
+	return blockParserFunc{name: name, priority: priority, open: open}
+}
+
+// NewInlineParser creates an InlineParser from a function
+func NewInlineParser(name string, priority int, parse func(content *Content, text string) string) InlineParser {
+	return inlineParserFunc{name: name, priority: priority, parse: parse}
+}
+
+type blockParserFunc struct {
+	name     string
+	priority int
+	open     func(content *Content) bool
+}
+
+func (self blockParserFunc) Name() string {
+	return self.name
+}
+
+func (self blockParserFunc) Priority() int {
+	return self.priority
+}
+
+func (self blockParserFunc) Open(content *Content) bool {
+	return self.open(content)
+}
+
+type inlineParserFunc struct {
+	name     string
+	priority int
+	parse    func(content *Content, text string) string
+}
+
+func (self inlineParserFunc) Name() string {
+	return self.name
+}
+
+func (self inlineParserFunc) Priority() int {
+	return self.priority
+}
+
+func (self inlineParserFunc) Parse(content *Content, text string) string {
+	return self.parse(content, text)
+}
@@ -0,0 +1,113 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+// admonitionParser is a multi line block parser for !!! ... !!! blocks
+type admonitionParser struct{}
+
+func (self admonitionParser) Name() string {
+	return "admonition"
+}
+
+func (self admonitionParser) Priority() int {
+	return PriorityCodeBlock + 50
+}
+
+func (self admonitionParser) Open(content *Content) bool {
+	if "!!!" != content.State.CurrentLineString {
+		return false
+	}
+	content.CloseParagraph()
+	content.State.BlockLines = nil
+	content.State.CurrentLineString = ""
+	content.StartBlock(self)
+	return true
+}
+
+func (self admonitionParser) Continue(content *Content) bool {
+	if "!!!" == content.State.CurrentLineString {
+		self.Close(content)
+		return false
+	}
+	content.State.BlockLines = append(content.State.BlockLines, content.ParseInline(content.State.CurrentLineString))
+	return true
+}
+
+func (self admonitionParser) Close(content *Content) {
+	content.Html = content.Html + "\n    <aside>" + strings.Join(content.State.BlockLines, " ") + "</aside>"
+	content.State.BlockLines = nil
+}
+
+func TestRegisterParsers(t *testing.T) {
+	RegisterInlineParser(NewInlineParser("mention", PriorityLinks-50, func(content *Content, text string) string {
+		return strings.ReplaceAll(text, "@bob", content.Protect("<a href='/u/bob'>@bob_b</a>"))
+	}))
+	RegisterBlockParser(NewBlockParser("rule", PriorityHeading+10, func(content *Content) bool {
+		if "***" != content.State.CurrentLineString {
+			return false
+		}
+		content.CloseParagraph()
+		content.State.CurrentLineString = "\n  <hr>"
+		return true
+	}))
+	RegisterBlockParser(admonitionParser{})
+	defer UnregisterParser("mention")
+	defer UnregisterParser("rule")
+	defer UnregisterParser("admonition")
+
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{"inline parser output is protected", "hi @bob", "hi <a href='/u/bob'>@bob_b</a>"},
+		{"block parser", "a\n***\nb", "a\n  </p>\n  <hr>\n  <p>\nb"},
+		{"multi line block parser", "!!!\n**x**\ny\n!!!", "<aside><b>x</b> y</aside>"},
+		{"unterminated multi line block", "!!!\nx", "<aside>x</aside>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := Content{Md: test.md}
+			content.Convert()
+			if !strings.Contains(content.Html, test.want) {
+				t.Errorf("expected %q in %q", test.want, content.Html)
+			}
+		})
+	}
+}
+
+func TestParserOrder(t *testing.T) {
+	RegisterInlineParser(NewInlineParser("first", PriorityInlineCode+10, func(content *Content, text string) string {
+		return text + "1"
+	}))
+	RegisterInlineParser(NewInlineParser("last", 0, func(content *Content, text string) string {
+		return text + "2"
+	}))
+	defer UnregisterParser("first")
+	defer UnregisterParser("last")
+
+	parsers := GetInlineParsers()
+	if "first" != parsers[0].Name() || "last" != parsers[len(parsers)-1].Name() {
+		t.Fatalf("expected parsers sorted by priority")
+	}
+	content := Content{}
+	if got := content.ParseInline("x"); "x12" != got {
+		t.Errorf("expected %q but got %q", "x12", got)
+	}
+
+	// registering a parser with an existing name replaces it
+	RegisterInlineParser(NewInlineParser("last", 0, func(content *Content, text string) string {
+		return text + "3"
+	}))
+	if got := content.ParseInline("x"); "x13" != got {
+		t.Errorf("expected %q but got %q", "x13", got)
+	}
+
+	UnregisterParser("first")
+	if got := content.ParseInline("x"); "x3" != got {
+		t.Errorf("expected %q but got %q", "x3", got)
+	}
+}
@@ -0,0 +1,130 @@
+package converter
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+const LinkRuleMatchExternal = "external"
+const LinkRuleMatchInternal = "internal"
+
+// DefaultNavLinkPolicy is used for link type nav entries if no link policy
+// is configured, external ones open in a new tab
+var DefaultNavLinkPolicy = &types.LinkPolicy{
+	Rules: []types.LinkRule{{Match: LinkRuleMatchExternal, Target: "_blank"}},
+}
+
+// LinkAttributes are the additional attributes rendered into an anchor
+type LinkAttributes struct {
+	Rel    []string
+	Target string
+	Class  []string
+}
+
+// GetLinkAttributes applies all rules of the link policy matching the given
+// href. A rule matches either "external" or "internal" links or a domain
+// including its subdomains. Links are external if they point to a host that
+// is neither the host of base nor listed in the internal domains.
+func GetLinkAttributes(policy *types.LinkPolicy, base string, href string) LinkAttributes {
+	attributes := LinkAttributes{}
+	if nil == policy {
+		return attributes
+	}
+	host := getLinkHost(href)
+	external := IsExternalLink(policy, base, href)
+	for _, rule := range policy.Rules {
+		matches := false
+		switch rule.Match {
+		case LinkRuleMatchExternal:
+			matches = external
+		case LinkRuleMatchInternal:
+			matches = !external
+		default:
+			matches = "" != host && isSameOrSubdomain(host, rule.Match)
+		}
+		if matches {
+			attributes = attributes.Merge(LinkAttributes{
+				Rel:    strings.Fields(rule.Rel),
+				Target: rule.Target,
+				Class:  strings.Fields(rule.Class),
+			})
+		}
+	}
+	return attributes
+}
+
+func IsExternalLink(policy *types.LinkPolicy, base string, href string) bool {
+	host := getLinkHost(href)
+	if "" == host {
+		// relative links, anchors and mailto: are never external
+		return false
+	}
+	if baseHost := getLinkHost(base); "" != baseHost && isSameOrSubdomain(host, baseHost) {
+		return false
+	}
+	if nil != policy {
+		for _, domain := range policy.InternalDomains {
+			if isSameOrSubdomain(host, domain) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Merge adds the rel and class values of other and overwrites the target if
+// other has one
+func (self LinkAttributes) Merge(other LinkAttributes) LinkAttributes {
+	for _, rel := range other.Rel {
+		if !util.StringInArray(self.Rel, rel) {
+			self.Rel = append(self.Rel, rel)
+		}
+	}
+	for _, class := range other.Class {
+		if !util.StringInArray(self.Class, class) {
+			self.Class = append(self.Class, class)
+		}
+	}
+	if "" != other.Target {
+		self.Target = other.Target
+	}
+	return self
+}
+
+func (self LinkAttributes) String() string {
+	attributes := ""
+	if 0 < len(self.Rel) {
+		attributes = attributes + " rel='" + escapeHtml(strings.Join(self.Rel, " ")) + "'"
+	}
+	if "" != self.Target {
+		attributes = attributes + " target='" + escapeHtml(self.Target) + "'"
+	}
+	if 0 < len(self.Class) {
+		attributes = attributes + " class='" + escapeHtml(strings.Join(self.Class, " ")) + "'"
+	}
+	return attributes
+}
+
+func (self *Content) getLinkAttributes(href string) LinkAttributes {
+	return GetLinkAttributes(self.Options.LinkPolicy, self.Options.Base, href)
+}
+
+func getLinkHost(href string) string {
+	if strings.HasPrefix(href, "//") {
+		href = "http:" + href
+	}
+	parsed, err := url.Parse(href)
+	if nil != err {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+func isSameOrSubdomain(host string, domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "www."))
+	host = strings.TrimPrefix(host, "www.")
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,83 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+var testLinkPolicy = &types.LinkPolicy{
+	InternalDomains: []string{"docs.example.com"},
+	Rules: []types.LinkRule{
+		{Match: LinkRuleMatchExternal, Rel: "noopener nofollow", Target: "_blank", Class: "external"},
+		{Match: "github.com", Rel: "noopener", Class: "github"},
+		{Match: LinkRuleMatchInternal, Class: "internal"},
+	},
+}
+
+func TestIsExternalLink(t *testing.T) {
+	tests := []struct {
+		href string
+		want bool
+	}{
+		{"page.html", false},
+		{"#anchor", false},
+		{"mailto:me@example.com", false},
+		{"https://example.com/a", false},
+		{"https://www.example.com/a", false},
+		{"https://sub.example.com/a", false},
+		{"https://docs.example.com/a", false},
+		{"https://github.com/a", true},
+		{"//cdn.other.org/x.js", true},
+	}
+	for _, test := range tests {
+		t.Run(test.href, func(t *testing.T) {
+			if got := IsExternalLink(testLinkPolicy, "https://example.com", test.href); test.want != got {
+				t.Errorf("expected %t but got %t", test.want, got)
+			}
+		})
+	}
+}
+
+func TestGetLinkAttributes(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy *types.LinkPolicy
+		href   string
+		want   string
+	}{
+		{"no policy", nil, "https://github.com", ""},
+		{"internal", testLinkPolicy, "page.html", " class='internal'"},
+		{"external", testLinkPolicy, "https://other.org", " rel='noopener nofollow' target='_blank' class='external'"},
+		{"rules are combined", testLinkPolicy, "https://gist.github.com", " rel='noopener nofollow' target='_blank' class='external github'"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := GetLinkAttributes(test.policy, "https://example.com", test.href).String(); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestConvertLinkPolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{"markdown link", "[a](https://other.org)", "<a href='https://other.org' rel='noopener nofollow' target='_blank' class='external'>a</a>"},
+		{"explicit blank kept", "[a](page.html _blank)", "<a href='page.html' target='_blank' class='internal'>a</a>"},
+		{"autolink", "https://other.org", "<a href='https://other.org' rel='noopener nofollow' target='_blank' class='external'>https://other.org</a>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := Content{Md: test.md, Options: Options{Base: "https://example.com", LinkPolicy: testLinkPolicy}}
+			content.Convert()
+			if !strings.Contains(content.Html, test.want) {
+				t.Errorf("expected %q in %q", test.want, content.Html)
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package converter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const mathmlNamespace = "http://www.w3.org/1998/Math/MathML"
+
+// tex commands that map onto a single identifier or operator
+var mathIdentifiers = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ε", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "varpi": "ϖ", "rho": "ρ",
+	"sigma": "σ", "tau": "τ", "upsilon": "υ", "phi": "ϕ", "varphi": "φ", "chi": "χ",
+	"psi": "ψ", "omega": "ω", "Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ",
+	"Xi": "Ξ", "Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	"infty": "∞", "partial": "∂", "nabla": "∇", "emptyset": "∅", "ell": "ℓ", "hbar": "ℏ",
+}
+
+var mathOperators = map[string]string{
+	"cdot": "⋅", "times": "×", "div": "÷", "pm": "±", "mp": "∓", "leq": "≤", "le": "≤",
+	"geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠", "approx": "≈", "equiv": "≡", "sim": "∼",
+	"propto": "∝", "in": "∈", "notin": "∉", "subset": "⊂", "subseteq": "⊆", "supset": "⊃",
+	"cup": "∪", "cap": "∩", "forall": "∀", "exists": "∃", "neg": "¬", "land": "∧", "lor": "∨",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "Rightarrow": "⇒", "Leftarrow": "⇐",
+	"leftrightarrow": "↔", "Leftrightarrow": "⇔", "mapsto": "↦", "ldots": "…", "cdots": "⋯",
+	"sum": "∑", "prod": "∏", "int": "∫", "oint": "∮", "lim": "lim", "langle": "⟨", "rangle": "⟩",
+	"{": "{", "}": "}", ",": " ", ";": " ", "quad": " ", "qquad": "  ",
+}
+
+var mathFunctions = []string{"sin", "cos", "tan", "cot", "sec", "csc", "log", "ln", "exp", "max", "min", "det", "sup", "inf", "arg"}
+
+const inlineMathRxp = `\\\$|\$\$([^$]+)\$\$|\$([^\s$](?:[^$]*[^\s\\$])?)\$([^0-9]|$)`
+
+func parseInlineMath(content *Content, text string) string {
+	if MathRendererNone == content.Options.MathRenderer {
+		return text
+	}
+	tmp := regexp.MustCompile(inlineMathRxp)
+	return tmp.ReplaceAllStringFunc(text, func(match string) string {
+		// an escaped dollar sign stays a literal one
+		if `\$` == match {
+			return content.Protect("$")
+		}
+		submatch := tmp.FindStringSubmatch(match)
+		// $$...$$ within a line is display math which stays in the paragraph
+		if "" != submatch[1] {
+			return content.Protect(content.renderInlineDisplayMath(submatch[1]))
+		}
+		return content.Protect(content.renderMath(submatch[2], false)) + submatch[3]
+	})
+}
+
+// mathBlockParser handles display math in $$ ... $$ either on a single
+// line or spanning multiple lines
+type mathBlockParser struct{}
+
+func (self mathBlockParser) Name() string {
+	return "mathblock"
+}
+
+func (self mathBlockParser) Priority() int {
+	return PriorityMathBlock
+}
+
+func (self mathBlockParser) Open(content *Content) bool {
+	if MathRendererNone == content.Options.MathRenderer || !strings.HasPrefix(content.State.CurrentLineString, "$$") {
+		return false
+	}
+	content.CloseParagraph()
+	rest := strings.TrimPrefix(content.State.CurrentLineString, "$$")
+	// single line display math like $$ a^2 + b^2 $$
+	if strings.HasSuffix(strings.TrimSpace(rest), "$$") {
+		content.State.CurrentLineString = content.renderMath(strings.TrimSuffix(strings.TrimSpace(rest), "$$"), true)
+		return true
+	}
+	content.State.BlockLines = nil
+	if "" != strings.TrimSpace(rest) {
+		content.State.BlockLines = append(content.State.BlockLines, rest)
+	}
+	content.State.CurrentLineString = ""
+	content.StartBlock(self)
+	return true
+}
+
+func (self mathBlockParser) Continue(content *Content) bool {
+	line := strings.TrimSpace(content.State.CurrentLineString)
+	if !strings.HasSuffix(line, "$$") {
+		content.State.BlockLines = append(content.State.BlockLines, content.State.CurrentLineString)
+		return true
+	}
+	if rest := strings.TrimSuffix(line, "$$"); "" != rest {
+		content.State.BlockLines = append(content.State.BlockLines, rest)
+	}
+	self.Close(content)
+	return false
+}
+
+// Close flushes the collected formula, an unterminated block is rendered as is
+func (self mathBlockParser) Close(content *Content) {
+	content.Html = content.Html + content.renderMath(strings.Join(content.State.BlockLines, "\n"), true)
+	content.State.BlockLines = nil
+}
+
+// renderMath renders a tex formula either as MathML or as an escaped
+// \(...\) / \[...\] span which is picked up by a client side renderer.
+// MathML keeps the tex source as alttext.
+func (self *Content) renderMath(tex string, display bool) string {
+	tex = strings.TrimSpace(tex)
+	if MathRendererMathML == self.Options.MathRenderer {
+		if display {
+			return "\n    <math xmlns='" + mathmlNamespace + "' display='block' alttext='" + escapeHtml(tex) + "'>" + TexToMathML(tex) + "</math>"
+		}
+		return "<math xmlns='" + mathmlNamespace + "' alttext='" + escapeHtml(tex) + "'>" + TexToMathML(tex) + "</math>"
+	}
+	if display {
+		return "\n    <div class='math display'>\\[" + escapeHtml(tex) + "\\]</div>"
+	}
+	return "<span class='math inline'>\\(" + escapeHtml(tex) + "\\)</span>"
+}
+
+// renderInlineDisplayMath renders $$...$$ found within a line in display
+// style, without the block element which would end the paragraph
+func (self *Content) renderInlineDisplayMath(tex string) string {
+	tex = strings.TrimSpace(tex)
+	if MathRendererMathML == self.Options.MathRenderer {
+		return "<math xmlns='" + mathmlNamespace + "' display='block' alttext='" + escapeHtml(tex) + "'>" + TexToMathML(tex) + "</math>"
+	}
+	return "<span class='math display'>\\[" + escapeHtml(tex) + "\\]</span>"
+}
+
+// TexToMathML translates the commonly used subset of tex math (groups,
+// sub-/superscripts, fractions, roots, greek letters and operators) into
+// presentation MathML. Unsupported commands are rendered as merror.
+func TexToMathML(tex string) string {
+	parser := texParser{input: []rune(tex)}
+	return "<mrow>" + parser.parseSequence(false) + "</mrow>"
+}
+
+type texParser struct {
+	input []rune
+	pos   int
+}
+
+func (self *texParser) parseSequence(inGroup bool) string {
+	var out strings.Builder
+	for self.pos < len(self.input) {
+		self.skipSpaces()
+		if self.pos >= len(self.input) {
+			break
+		}
+		if '}' == self.input[self.pos] {
+			if inGroup {
+				self.pos++
+				return out.String()
+			}
+			// stray closing brace
+			self.pos++
+			continue
+		}
+		out.WriteString(self.parseScripts(self.parseAtom()))
+	}
+	return out.String()
+}
+
+func (self *texParser) parseScripts(base string) string {
+	var sub, sup string
+	for {
+		self.skipSpaces()
+		if self.pos >= len(self.input) {
+			break
+		}
+		r := self.input[self.pos]
+		if '_' == r && "" == sub {
+			self.pos++
+			sub = self.parseArgument()
+		} else if '^' == r && "" == sup {
+			self.pos++
+			sup = self.parseArgument()
+		} else {
+			break
+		}
+	}
+	if "" != sub && "" != sup {
+		return "<msubsup>" + base + sub + sup + "</msubsup>"
+	} else if "" != sub {
+		return "<msub>" + base + sub + "</msub>"
+	} else if "" != sup {
+		return "<msup>" + base + sup + "</msup>"
+	}
+	return base
+}
+
+// parseArgument parses a single atom wrapped in an mrow, as used for
+// arguments of commands and scripts
+func (self *texParser) parseArgument() string {
+	self.skipSpaces()
+	if self.pos >= len(self.input) {
+		return "<mrow></mrow>"
+	}
+	if '{' == self.input[self.pos] {
+		self.pos++
+		return "<mrow>" + self.parseSequence(true) + "</mrow>"
+	}
+	// like in tex a digit argument is a single digit, \frac12 is 1/2
+	if unicode.IsDigit(self.input[self.pos]) {
+		self.pos++
+		return "<mrow><mn>" + string(self.input[self.pos-1]) + "</mn></mrow>"
+	}
+	return "<mrow>" + self.parseAtom() + "</mrow>"
+}
+
+func (self *texParser) parseAtom() string {
+	r := self.input[self.pos]
+	switch {
+	case '{' == r:
+		self.pos++
+		return "<mrow>" + self.parseSequence(true) + "</mrow>"
+	case '\\' == r:
+		self.pos++
+		return self.parseCommand()
+	case unicode.IsDigit(r):
+		start := self.pos
+		for self.pos < len(self.input) && (unicode.IsDigit(self.input[self.pos]) || '.' == self.input[self.pos]) {
+			self.pos++
+		}
+		return "<mn>" + string(self.input[start:self.pos]) + "</mn>"
+	case unicode.IsLetter(r):
+		self.pos++
+		return "<mi>" + string(r) + "</mi>"
+	case '&' == r:
+		self.pos++
+		return ""
+	}
+	self.pos++
+	return "<mo>" + escapeHtml(string(r)) + "</mo>"
+}
+
+func (self *texParser) parseCommand() string {
+	if self.pos >= len(self.input) {
+		return ""
+	}
+	start := self.pos
+	if !unicode.IsLetter(self.input[self.pos]) {
+		// single character commands like \{ \, \\
+		self.pos++
+	} else {
+		for self.pos < len(self.input) && unicode.IsLetter(self.input[self.pos]) {
+			self.pos++
+		}
+	}
+	name := string(self.input[start:self.pos])
+
+	switch name {
+	case "\\":
+		return ""
+	case "frac":
+		return "<mfrac>" + self.parseArgument() + self.parseArgument() + "</mfrac>"
+	case "sqrt":
+		self.skipSpaces()
+		if self.pos < len(self.input) && '[' == self.input[self.pos] {
+			end := self.pos
+			for end < len(self.input) && ']' != self.input[end] {
+				end++
+			}
+			index := TexToMathML(string(self.input[self.pos+1 : end]))
+			self.pos = end + 1
+			return "<mroot>" + self.parseArgument() + index + "</mroot>"
+		}
+		return "<msqrt>" + self.parseArgument() + "</msqrt>"
+	case "text", "mathrm", "textrm", "operatorname":
+		return "<mtext>" + escapeHtml(self.readRawGroup()) + "</mtext>"
+	case "left", "right", "big", "Big", "bigl", "bigr":
+		// sizing only, the following delimiter is rendered as is
+		return ""
+	}
+	if val, ok := mathIdentifiers[name]; ok {
+		return "<mi>" + val + "</mi>"
+	}
+	if val, ok := mathOperators[name]; ok {
+		return "<mo>" + val + "</mo>"
+	}
+	for _, function := range mathFunctions {
+		if function == name {
+			return "<mi>" + name + "</mi>"
+		}
+	}
+	return "<merror><mtext>\\" + escapeHtml(name) + "</mtext></merror>"
+}
+
+// readRawGroup returns the unparsed content of the following {...} group
+func (self *texParser) readRawGroup() string {
+	self.skipSpaces()
+	if self.pos >= len(self.input) || '{' != self.input[self.pos] {
+		return ""
+	}
+	depth := 0
+	start := self.pos + 1
+	for ; self.pos < len(self.input); self.pos++ {
+		if '{' == self.input[self.pos] {
+			depth++
+		} else if '}' == self.input[self.pos] {
+			depth--
+			if 0 == depth {
+				self.pos++
+				return string(self.input[start : self.pos-1])
+			}
+		}
+	}
+	return string(self.input[start:])
+}
+
+func (self *texParser) skipSpaces() {
+	for self.pos < len(self.input) && unicode.IsSpace(self.input[self.pos]) {
+		self.pos++
+	}
+}
+
+func escapeHtml(text string) string {
+	replacer := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "'", "&#39;", "\"", "&quot;")
+	return replacer.Replace(text)
+}
@@ -0,0 +1,123 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTexToMathML(t *testing.T) {
+	tests := []struct {
+		name string
+		tex  string
+		want string
+	}{
+		{"empty", "", "<mrow></mrow>"},
+		{"identifier", "x", "<mrow><mi>x</mi></mrow>"},
+		{"number", "3.14", "<mrow><mn>3.14</mn></mrow>"},
+		{"operator", "a < b", "<mrow><mi>a</mi><mo>&lt;</mo><mi>b</mi></mrow>"},
+		{"superscript", "x^2", "<mrow><msup><mi>x</mi><mrow><mn>2</mn></mrow></msup></mrow>"},
+		{"subscript", "a_i", "<mrow><msub><mi>a</mi><mrow><mi>i</mi></mrow></msub></mrow>"},
+		{"sub and superscript", "x_i^2", "<mrow><msubsup><mi>x</mi><mrow><mi>i</mi></mrow><mrow><mn>2</mn></mrow></msubsup></mrow>"},
+		{"grouped script", "x^{n+1}", "<mrow><msup><mi>x</mi><mrow><mi>n</mi><mo>+</mo><mn>1</mn></mrow></msup></mrow>"},
+		{"single digit script", "x^23", "<mrow><msup><mi>x</mi><mrow><mn>2</mn></mrow></msup><mn>3</mn></mrow>"},
+		{"fraction", `\frac{a}{b}`, "<mrow><mfrac><mrow><mi>a</mi></mrow><mrow><mi>b</mi></mrow></mfrac></mrow>"},
+		{"fraction of digits", `\frac12`, "<mrow><mfrac><mrow><mn>1</mn></mrow><mrow><mn>2</mn></mrow></mfrac></mrow>"},
+		{"square root", `\sqrt{x}`, "<mrow><msqrt><mrow><mi>x</mi></mrow></msqrt></mrow>"},
+		{"nth root", `\sqrt[3]{x}`, "<mrow><mroot><mrow><mi>x</mi></mrow><mrow><mn>3</mn></mrow></mroot></mrow>"},
+		{"greek letters", `\alpha + \Omega`, "<mrow><mi>α</mi><mo>+</mo><mi>Ω</mi></mrow>"},
+		{"command operator", `a \leq b`, "<mrow><mi>a</mi><mo>≤</mo><mi>b</mi></mrow>"},
+		{"function", `\sin x`, "<mrow><mi>sin</mi><mi>x</mi></mrow>"},
+		{"text", `\text{if } x`, "<mrow><mtext>if </mtext><mi>x</mi></mrow>"},
+		{"escaped braces", `\{ x \}`, "<mrow><mo>{</mo><mi>x</mi><mo>}</mo></mrow>"},
+		{"sizing commands", `\left( x \right)`, "<mrow><mo>(</mo><mi>x</mi><mo>)</mo></mrow>"},
+		{"sum with limits", `\sum_{i=1}^{n} i`, "<mrow><msubsup><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mrow><mi>n</mi></mrow></msubsup><mi>i</mi></mrow>"},
+		{"stray closing brace", "a}", "<mrow><mi>a</mi></mrow>"},
+		{"unknown command", `\foo`, "<mrow><merror><mtext>\\foo</mtext></merror></mrow>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := TexToMathML(test.tex); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestMathRenderer(t *testing.T) {
+	tests := []struct {
+		renderer string
+		want     string
+	}{
+		{MathRendererNone, "a $x$ b"},
+		{MathRendererClient, "a <span class='math inline'>\\(x\\)</span> b"},
+		{MathRendererMathML, "a <math xmlns='" + mathmlNamespace + "' alttext='x'><mrow><mi>x</mi></mrow></math> b"},
+	}
+	for _, test := range tests {
+		t.Run(test.renderer, func(t *testing.T) {
+			content := Content{Md: "a $x$ b", Options: Options{MathRenderer: test.renderer}}
+			content.Convert()
+			if !strings.Contains(content.Html, test.want) {
+				t.Errorf("expected %q in %q", test.want, content.Html)
+			}
+		})
+	}
+}
+
+func TestConvertMath(t *testing.T) {
+	tests := []struct {
+		name    string
+		md      string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "inline math keeps emphasis characters",
+			md:   "see $x_1 * y_2$ and _this_",
+			want: []string{`<span class='math inline'>\(x_1 * y_2\)</span>`, "<i>this</i>"},
+		},
+		{
+			name:    "amounts are no math",
+			md:      "costs $5 and $10",
+			want:    []string{"costs $5 and $10"},
+			notWant: []string{"math"},
+		},
+		{
+			name:    "escaped dollar",
+			md:      `a \$x$ b`,
+			want:    []string{"a $x$ b"},
+			notWant: []string{"math"},
+		},
+		{
+			name:    "display math within a line",
+			md:      "area $$a^2$$ here",
+			want:    []string{`area <span class='math display'>\[a^2\]</span> here`},
+			notWant: []string{"$"},
+		},
+		{
+			name: "single line display math",
+			md:   "$$ a^2 + b^2 $$",
+			want: []string{`<div class='math display'>\[a^2 + b^2\]</div>`},
+		},
+		{
+			name: "multi line display math",
+			md:   "$$\na < b\n$$\nafter",
+			want: []string{`<div class='math display'>\[a &lt; b\]</div>`, "after"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := Content{Md: test.md, Options: Options{MathRenderer: MathRendererClient}}
+			content.Convert()
+			for _, want := range test.want {
+				if !strings.Contains(content.Html, want) {
+					t.Errorf("expected %q in %q", want, content.Html)
+				}
+			}
+			for _, notWant := range test.notWant {
+				if strings.Contains(content.Html, notWant) {
+					t.Errorf("did not expect %q in %q", notWant, content.Html)
+				}
+			}
+		})
+	}
+}
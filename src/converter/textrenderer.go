@@ -0,0 +1,359 @@
+package converter
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const FormatGemtext = "gmi"
+const FormatText = "txt"
+
+const htmlAttributeRxp = `\s%s\s*=\s*(?:'([^']*)'|"([^"]*)"|([^\s>]+))`
+
+// elements which end the text block they are in
+var textBlockElements = []string{"div", "p", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "li", "blockquote", "pre", "table", "tr", "section", "article", "header", "footer", "nav", "aside", "figure", "hr"}
+
+// textBlock is a block of the converted html as seen by the gemtext and
+// text renderers
+type textBlock struct {
+	Kind  string
+	Level int
+	Info  string
+	Text  string
+	Lines []string
+	Links []textLink
+}
+
+// textLink is a link collected from the inline content of a block
+type textLink struct {
+	Text string
+	Url  string
+}
+
+// ToGemtext renders the content as Gemini gemtext. Headings are kept (up to
+// level 3), paragraphs become single lines, lists are flattened and all links
+// and images of a block are emitted as link lines right after it. Relative
+// links to .html pages point to their .gmi sibling. The markdown is
+// converted first unless the content already holds its html.
+func (self *Content) ToGemtext() string {
+	var out []string
+	for _, block := range parseTextBlocks(self.getHtml(), nil) {
+		switch block.Kind {
+		case "code":
+			out = append(out, "```"+block.Info)
+			out = append(out, block.Lines...)
+			out = append(out, "```")
+			continue
+		case "heading":
+			level := block.Level
+			if 3 < level {
+				level = 3
+			}
+			out = append(out, strings.Repeat("#", level)+" "+block.Text)
+			continue
+		case "item":
+			out = append(out, "* "+block.Text)
+		case "quote":
+			out = append(out, "> "+block.Text)
+		default:
+			out = append(out, block.Text)
+		}
+		for _, link := range block.Links {
+			url := link.Url
+			if !strings.Contains(url, ":") && strings.Contains(url, ".html") {
+				url = strings.Replace(url, ".html", ".gmi", 1)
+			}
+			if isLinkText(link.Text, link.Url) || "" == link.Text {
+				out = append(out, "=> "+url)
+			} else {
+				out = append(out, "=> "+url+" "+link.Text)
+			}
+		}
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// ToText renders the content as plain text wrapped at the given width,
+// links are written as "text (url)". Relative urls are prefixed with the
+// base url since plain text has no base to resolve them against.
+func (self *Content) ToText(width int) string {
+	var out []string
+	blocks := parseTextBlocks(self.getHtml(), func(text string, url string) string {
+		if isLinkText(text, url) || "" == text {
+			return text
+		}
+		if !strings.Contains(url, ":") && !strings.HasPrefix(url, "#") && !strings.HasPrefix(url, "/") {
+			url = self.Options.Base + url
+		}
+		return text + " (" + url + ")"
+	})
+	for i, block := range blocks {
+		switch block.Kind {
+		case "code":
+			for _, line := range block.Lines {
+				out = append(out, "    "+line)
+			}
+		case "heading":
+			underline := "-"
+			if 1 == block.Level {
+				underline = "="
+			}
+			out = append(out, block.Text, strings.Repeat(underline, len([]rune(block.Text))))
+		case "item":
+			out = append(out, wrapText(block.Text, width, "  * ", "    ")...)
+		case "quote":
+			out = append(out, wrapText(block.Text, width, "> ", "> ")...)
+		default:
+			out = append(out, wrapText(block.Text, width, "", "")...)
+		}
+		// list items are kept together, everything else is separated
+		if "item" != block.Kind || i+1 == len(blocks) || "item" != blocks[i+1].Kind {
+			out = append(out, "")
+		}
+	}
+	return strings.Trim(strings.Join(out, "\n"), "\n") + "\n"
+}
+
+func (self *Content) getHtml() string {
+	if "" == self.Html {
+		self.Convert()
+	}
+	return self.Html
+}
+
+// isLinkText reports whether the link text is just the url, like for
+// autolinks, so it doesn't need to be repeated
+func isLinkText(text string, url string) bool {
+	return text == url || text == strings.TrimPrefix(url, "mailto:") || text == strings.TrimPrefix(url, "http://")
+}
+
+// parseTextBlocks walks the html and splits it into headings, paragraphs,
+// list items, quotes and code blocks. Display math becomes a code block and
+// inline math is written as $tex$. Links and images are collected per
+// block, annotate optionally rewrites the text of a link.
+func parseTextBlocks(source string, annotate func(text string, url string) string) []textBlock {
+	var blocks []textBlock
+	var current *textBlock
+	var text strings.Builder
+	var linkText strings.Builder
+	linkUrl := ""
+	inLink := false
+	quoteDepth := 0
+
+	write := func(str string) {
+		if inLink {
+			linkText.WriteString(str)
+		} else {
+			text.WriteString(str)
+		}
+	}
+	flush := func() {
+		if nil != current {
+			current.Text = strings.Join(strings.Fields(text.String()), " ")
+			if "" != current.Text || 0 < len(current.Links) {
+				blocks = append(blocks, *current)
+			}
+		}
+		current = nil
+		text.Reset()
+	}
+	open := func(kind string, level int) {
+		flush()
+		current = &textBlock{Kind: kind, Level: level}
+	}
+	// text outside of any block, like in html pages, is a paragraph
+	ensureBlock := func() {
+		if nil == current {
+			kind := "paragraph"
+			if 0 < quoteDepth {
+				kind = "quote"
+			}
+			current = &textBlock{Kind: kind}
+		}
+	}
+	addLink := func(linkText string, url string) {
+		ensureBlock()
+		current.Links = append(current.Links, textLink{Text: linkText, Url: url})
+		if nil != annotate {
+			linkText = annotate(linkText, url)
+		}
+		write(linkText)
+	}
+
+	for i := 0; i < len(source); {
+		if '<' != source[i] {
+			end := strings.IndexByte(source[i:], '<')
+			if -1 == end {
+				end = len(source)
+			} else {
+				end += i
+			}
+			if content := html.UnescapeString(source[i:end]); "" != strings.TrimSpace(content) || nil != current {
+				ensureBlock()
+				write(content)
+			}
+			i = end
+			continue
+		}
+		if strings.HasPrefix(source[i:], "<!--") {
+			end := strings.Index(source[i:], "-->")
+			if -1 == end {
+				break
+			}
+			i += end + 3
+			continue
+		}
+		end := strings.IndexByte(source[i:], '>')
+		if -1 == end {
+			break
+		}
+		tag := source[i : i+end+1]
+		i += end + 1
+		name := getTagName(tag)
+		closing := strings.HasPrefix(tag, "</")
+
+		switch {
+		case "pre" == name && !closing:
+			// code is taken as is up to the end of the element
+			inner, next := getElementContent(source, i, "pre")
+			i = next
+			info := ""
+			if match := regexp.MustCompile(`language-([^\s'"]+)`).FindStringSubmatch(inner); nil != match {
+				info = match[1]
+			}
+			code := strings.TrimRight(strings.TrimLeft(html.UnescapeString(stripTags(inner)), "\n"), " \n")
+			flush()
+			blocks = append(blocks, textBlock{Kind: "code", Info: info, Lines: strings.Split(code, "\n")})
+		case "math" == name && !closing:
+			_, next := getElementContent(source, i, "math")
+			i = next
+			tex := getHtmlAttribute(tag, "alttext")
+			if nil != current {
+				if "block" == getHtmlAttribute(tag, "display") {
+					write("$$" + tex + "$$")
+				} else {
+					write("$" + tex + "$")
+				}
+			} else {
+				blocks = append(blocks, textBlock{Kind: "code", Info: "math", Lines: strings.Split(tex, "\n")})
+			}
+		case ("span" == name || "div" == name) && !closing && strings.HasPrefix(getHtmlAttribute(tag, "class"), "math "):
+			inner, next := getElementContent(source, i, name)
+			i = next
+			tex := html.UnescapeString(inner)
+			if "div" == name {
+				flush()
+				tex = strings.TrimSuffix(strings.TrimPrefix(tex, `\[`), `\]`)
+				blocks = append(blocks, textBlock{Kind: "code", Info: "math", Lines: strings.Split(tex, "\n")})
+			} else if strings.HasPrefix(tex, `\[`) {
+				ensureBlock()
+				write("$$" + strings.TrimSuffix(strings.TrimPrefix(tex, `\[`), `\]`) + "$$")
+			} else {
+				ensureBlock()
+				write("$" + strings.TrimSuffix(strings.TrimPrefix(tex, `\(`), `\)`) + "$")
+			}
+		case "h1" == name || "h2" == name || "h3" == name || "h4" == name || "h5" == name || "h6" == name:
+			if closing {
+				flush()
+			} else {
+				level, _ := strconv.Atoi(name[1:])
+				open("heading", level)
+			}
+		case "li" == name && !closing:
+			open("item", 0)
+		case "blockquote" == name:
+			flush()
+			if closing {
+				quoteDepth--
+			} else {
+				quoteDepth++
+			}
+		case "a" == name:
+			if closing && inLink {
+				inLink = false
+				addLink(strings.Join(strings.Fields(linkText.String()), " "), linkUrl)
+			} else if !closing {
+				ensureBlock()
+				inLink = true
+				linkUrl = getHtmlAttribute(tag, "href")
+				linkText.Reset()
+			}
+		case "img" == name:
+			addLink(getHtmlAttribute(tag, "alt"), getHtmlAttribute(tag, "src"))
+		case "video" == name && !closing:
+			// the fallback text of the video is skipped
+			_, next := getElementContent(source, i, "video")
+			sources := regexp.MustCompile(`<source[^>]*>`).FindAllString(source[i:next], -1)
+			i = next
+			for _, sourceTag := range sources {
+				addLink("video", getHtmlAttribute(sourceTag, "src"))
+			}
+		case "br" == name:
+			write(" ")
+		case "script" == name || "style" == name:
+			if !closing {
+				_, i = getElementContent(source, i, name)
+			}
+		default:
+			for _, element := range textBlockElements {
+				if element == name {
+					flush()
+					break
+				}
+			}
+		}
+	}
+	flush()
+	return blocks
+}
+
+// getElementContent returns the content of the element starting at pos up
+// to its closing tag and the position after it
+func getElementContent(source string, pos int, name string) (string, int) {
+	end := strings.Index(source[pos:], "</"+name+">")
+	if -1 == end {
+		return source[pos:], len(source)
+	}
+	return source[pos : pos+end], pos + end + len(name) + 3
+}
+
+// getHtmlAttribute returns the unescaped value of the attribute of a tag
+func getHtmlAttribute(tag string, name string) string {
+	match := regexp.MustCompile(strings.Replace(htmlAttributeRxp, "%s", regexp.QuoteMeta(name), 1)).FindStringSubmatch(tag)
+	if nil == match {
+		return ""
+	}
+	return html.UnescapeString(match[1] + match[2] + match[3])
+}
+
+func stripTags(source string) string {
+	return regexp.MustCompile(`<[^>]*>`).ReplaceAllString(source, "")
+}
+
+// wrapText wraps text at width, the first line is prefixed with prefix and
+// all following lines with indent
+func wrapText(text string, width int, prefix string, indent string) []string {
+	var lines []string
+	line := prefix
+	lineLen := len([]rune(prefix))
+	empty := true
+	for _, word := range strings.Fields(text) {
+		wordLen := len([]rune(word))
+		if !empty && 0 < width && lineLen+1+wordLen > width {
+			lines = append(lines, line)
+			line = indent
+			lineLen = len([]rune(indent))
+			empty = true
+		}
+		if !empty {
+			line = line + " "
+			lineLen++
+		}
+		line = line + word
+		lineLen = lineLen + wordLen
+		empty = false
+	}
+	return append(lines, line)
+}
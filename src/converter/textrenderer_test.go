@@ -0,0 +1,82 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToGemtext(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		md      string
+		want    string
+	}{
+		{"heading levels", Options{}, "# A\n\n#### B", "# A\n### B\n"},
+		{"paragraph lines are joined", Options{}, "a **b**\nc _d_", "a b c d\n"},
+		{"links follow their block", Options{}, "see [docs](2.Docs.html) and [x](https://x.org)", "see docs and x\n=> 2.Docs.gmi docs\n=> https://x.org x\n"},
+		{"bare www gets a scheme", Options{}, "see www.foo.com", "see www.foo.com\n=> http://www.foo.com\n"},
+		{"list", Options{}, "- a\n- [b](https://x.org)", "* a\n* b\n=> https://x.org b\n"},
+		{"quote", Options{}, "> a\n> *b*", "> a b\n"},
+		{"code is kept", Options{}, "```go\nif a < b {\n}\n```", "```go\nif a < b {\n}\n```\n"},
+		{"image", Options{}, "![a cat](cat.png)", "a cat\n=> cat.png a cat\n"},
+		{"inline math keeps emphasis characters", Options{MathRenderer: MathRendererClient}, "$x_1 * y_2$ is *it*", "$x_1 * y_2$ is it\n"},
+		{"inline display math", Options{MathRenderer: MathRendererMathML}, "a $$b^2$$ c", "a $$b^2$$ c\n"},
+		{"display math", Options{MathRenderer: MathRendererMathML}, "$$\na < b\n$$", "```math\na < b\n```\n"},
+		{"client display math", Options{MathRenderer: MathRendererClient}, "$$ a < b $$", "```math\na < b\n```\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := Content{Md: test.md, Options: test.options}
+			if got := content.ToGemtext(); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestToText(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{"headings are underlined", "# Title\n\n## Sub", "Title\n=====\n\nSub\n---\n"},
+		{"paragraphs are wrapped", "one two three four five six", "one two three four\nfive six\n"},
+		{"relative links get the base", "[docs](docs.html)", "docs\n(https://ex.org/docs.html)\n"},
+		{"autolinks are not repeated", "<https://x.org>", "https://x.org\n"},
+		{"list items stay together", "- a\n- b\n\nc", "  * a\n  * b\n\nc\n"},
+		{"code is indented", "```\n<b>\n```", "    <b>\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := Content{Md: test.md, Options: Options{Base: "https://ex.org/"}}
+			if got := content.ToText(20); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestTextFormatsUseExtensions(t *testing.T) {
+	RegisterBlockRenderer("mermaid", WrapBlockRenderer{Open: "<pre class='mermaid'>", Close: "</pre>"})
+	RegisterInlineParser(NewInlineParser("mention", PriorityLinks-50, func(content *Content, text string) string {
+		return strings.ReplaceAll(text, "@bob", content.Protect("<a href='https://x.org/bob'>@bob</a>"))
+	}))
+	defer delete(blockRenderers, "mermaid")
+	defer UnregisterParser("mention")
+
+	content := Content{Md: "hi @bob\n\n```mermaid\na --> b\n```"}
+	want := "hi @bob\n=> https://x.org/bob @bob\n```\na --> b\n```\n"
+	if got := content.ToGemtext(); want != got {
+		t.Errorf("expected %q but got %q", want, got)
+	}
+}
+
+func TestHtmlToGemtext(t *testing.T) {
+	content := Content{Html: "<h2>Hi</h2>\n<div class='note'><strong>Note:</strong> text &amp; more<br>next</div><script>var a = '<p>';</script><p>end</p>"}
+	want := "## Hi\nNote: text & more next\nend\n"
+	if got := content.ToGemtext(); want != got {
+		t.Errorf("expected %q but got %q", want, got)
+	}
+}
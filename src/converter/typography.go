@@ -0,0 +1,162 @@
+package converter
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+type quoteStyle struct {
+	DoubleOpen  string
+	DoubleClose string
+	SingleOpen  string
+	SingleClose string
+}
+
+var quoteStyles = map[string]quoteStyle{
+	"en": {"“", "”", "‘", "’"},
+	"de": {"„", "“", "‚", "‘"},
+	"ch": {"«", "»", "‹", "›"},
+	"fr": {"« ", " »", "‹ ", " ›"},
+	"pl": {"„", "”", "‚", "’"},
+	"sv": {"”", "”", "’", "’"},
+}
+
+// elements whose text must never be touched by the typography pass
+var typographySkipElements = []string{"code", "pre", "kbd", "samp", "script", "style", "math"}
+
+func parseTypography(content *Content, text string) string {
+	if !content.Options.SmartTypography {
+		return text
+	}
+	return applyTypography(text, getQuoteStyle(content.Options.TypographyLocale))
+}
+
+func getQuoteStyle(locale string) quoteStyle {
+	locale = strings.ToLower(locale)
+	if style, ok := quoteStyles[locale]; ok {
+		return style
+	}
+	// de-at, en-gb etc fall back to their language
+	if idx := strings.IndexAny(locale, "-_"); -1 != idx {
+		if style, ok := quoteStyles[locale[:idx]]; ok {
+			return style
+		}
+	}
+	return quoteStyles["en"]
+}
+
+// applyTypography turns straight quotes into curly ones, -- and --- into
+// en and em dashes and ... into an ellipsis. Like applyOutsideTags it only
+// touches text, not tags and their attributes. Additionally the content of
+// code like elements and urls written as text are skipped.
+func applyTypography(s string, quotes quoteStyle) string {
+	var out strings.Builder
+	runes := []rune(s)
+	prev := ' '
+	skipElement := ""
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		// copy tags as they are and keep track of skipped elements
+		if '<' == r {
+			end := i
+			for end < len(runes) && '>' != runes[end] {
+				end++
+			}
+			if end == len(runes) {
+				end--
+			}
+			tag := string(runes[i : end+1])
+			out.WriteString(tag)
+			name := getTagName(tag)
+			if "" == skipElement && !strings.HasPrefix(tag, "</") && !strings.HasSuffix(tag, "/>") && util.StringInArray(typographySkipElements, name) {
+				skipElement = name
+			} else if "" != skipElement && "</"+skipElement+">" == strings.ReplaceAll(tag, " ", "") {
+				skipElement = ""
+			}
+			i = end
+			continue
+		}
+		if "" != skipElement {
+			out.WriteRune(r)
+			continue
+		}
+
+		// urls in text are copied up to the next whitespace
+		if isUrlStart(runes[i:]) && !unicode.IsLetter(prev) {
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && '<' != runes[i] {
+				out.WriteRune(runes[i])
+				i++
+			}
+			i--
+			prev = 'x'
+			continue
+		}
+
+		switch {
+		case '.' == r && hasRunesAt(runes, i, "..."):
+			out.WriteString("…")
+			i += 2
+		case '-' == r && hasRunesAt(runes, i, "---"):
+			out.WriteString("—")
+			i += 2
+		case '-' == r && hasRunesAt(runes, i, "--"):
+			out.WriteString("–")
+			i += 1
+		case '"' == r:
+			if isOpeningContext(prev) {
+				out.WriteString(quotes.DoubleOpen)
+			} else {
+				out.WriteString(quotes.DoubleClose)
+			}
+		case '\'' == r:
+			next := ' '
+			if i+1 < len(runes) {
+				next = runes[i+1]
+			}
+			if unicode.IsLetter(prev) && unicode.IsLetter(next) {
+				// apostrophe inside a word like don't
+				out.WriteString("’")
+			} else if isOpeningContext(prev) {
+				out.WriteString(quotes.SingleOpen)
+			} else {
+				out.WriteString(quotes.SingleClose)
+			}
+		default:
+			out.WriteRune(r)
+		}
+		prev = r
+	}
+	return out.String()
+}
+
+func isOpeningContext(prev rune) bool {
+	return unicode.IsSpace(prev) || strings.ContainsRune("([{-–— ", prev)
+}
+
+func isUrlStart(runes []rune) bool {
+	if 8 < len(runes) {
+		runes = runes[:8]
+	}
+	rest := strings.ToLower(string(runes))
+	return strings.HasPrefix(rest, "http://") || strings.HasPrefix(rest, "https://") || strings.HasPrefix(rest, "www.") || strings.HasPrefix(rest, "mailto:")
+}
+
+func hasRunesAt(runes []rune, pos int, needle string) bool {
+	for i, r := range []rune(needle) {
+		if pos+i >= len(runes) || runes[pos+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func getTagName(tag string) string {
+	name := strings.TrimLeft(tag, "</")
+	if idx := strings.IndexAny(name, " \t/>"); -1 != idx {
+		name = name[:idx]
+	}
+	return strings.ToLower(name)
+}
@@ -0,0 +1,56 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyTypography(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		text   string
+		want   string
+	}{
+		{"double quotes", "en", `say "hi" now`, "say “hi” now"},
+		{"single quotes", "en", `say 'hi' now`, "say ‘hi’ now"},
+		{"apostrophe", "en", `don't`, "don’t"},
+		{"german quotes", "de", `sag "hallo"`, "sag „hallo“"},
+		{"locale fallback to language", "de-AT", `"x"`, "„x“"},
+		{"unknown locale", "xx", `"x"`, "“x”"},
+		{"dashes", "en", "a -- b --- c", "a – b — c"},
+		{"ellipsis", "en", "wait...", "wait…"},
+		{"attributes untouched", "en", `<a href='a--b' title="x">"y"</a>`, `<a href='a--b' title="x">“y”</a>`},
+		{"code untouched", "en", `<code>"a" -- b</code> "c"`, `<code>"a" -- b</code> “c”`},
+		{"urls untouched", "en", "see https://x.org/a--b...", "see https://x.org/a--b..."},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := applyTypography(test.text, getQuoteStyle(test.locale)); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestConvertTypography(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		md      string
+		want    string
+	}{
+		{"disabled", Options{}, `"a" -- b`, `"a" -- b`},
+		{"enabled", Options{SmartTypography: true, TypographyLocale: "en"}, `"a" -- b`, "“a” – b"},
+		{"inline code kept", Options{SmartTypography: true, TypographyLocale: "en"}, "`\"a\" -- b`", "<code>&quot;a&quot; -- b</code>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := Content{Md: test.md, Options: test.options}
+			content.Convert()
+			if !strings.Contains(content.Html, test.want) {
+				t.Errorf("expected %q in %q", test.want, content.Html)
+			}
+		})
+	}
+}
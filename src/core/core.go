@@ -3,10 +3,14 @@ package core
 import (
     _ "embed"
     "github.com/voodooEntity/gomcmf/src/config"
+    "github.com/voodooEntity/gomcmf/src/converter"
     "github.com/voodooEntity/gomcmf/src/template"
     "github.com/voodooEntity/gomcmf/src/types"
     "github.com/voodooEntity/gomcmf/src/util"
+	"io"
+	"os"
     "path/filepath"
+	"sort"
     "strconv"
     "strings"
     "time"
@@ -32,6 +36,8 @@ type Core struct {
 	Type     string
 	Target   string
 	Input    string
+	Output   string
+	Template string
 	Pwd      string
 }
 
@@ -63,12 +69,31 @@ func (self *Core) BuildProject() {
 	util.Print("- Main template file: '" + config.GetValue("mainFile") + "'")
 	util.Print("- Resources directory: '" + config.GetValue("resourcesPath") + "'")
 
- // read main template
- mainTemplate := util.ReadFile(filepath.Join(self.Pwd, config.GetValue("mainFile")))
-	mainTemplateReplacements, err := template.GetReplacementMarkers(mainTemplate)
-	if nil != err {
-		util.Error("Getting replacements for main template failed with error '" + err.Error() + "'")
+	// converter and template settings
+	converterOptions := self.getConverterOptions()
+	if nil != converterOptions.LinkPolicy {
+		util.Print("- Applying link policy with " + strconv.Itoa(len(converterOptions.LinkPolicy.Rules)) + " rules")
+	}
+	template.SetConverterOptions(converterOptions)
+	if languages := self.registerBlockRenderers(); 0 < len(languages) {
+		util.Print("- Code block renderers for '" + strings.Join(languages, "', '") + "'")
 	}
+	outputFormats := getOutputFormats()
+	if 0 < len(outputFormats) {
+		util.Print("- Additional output formats: '" + strings.Join(outputFormats, ", ") + "'")
+	}
+	template.LoadShortcodes(filepath.Join(self.Pwd, config.GetValueOrDefault("shortcodesPath", "shortcodes")))
+	template.SetPartialsPath(self.Pwd, config.GetValueOrDefault("partialsPath", "partials"))
+	template.LoadData(filepath.Join(self.Pwd, config.GetValueOrDefault("dataPath", "data")))
+	self.setDateOptions(startTime)
+	variables[template.BuildDateVariable] = template.FormatDate(startTime, "")
+
+
+ // read main template, it is the layout of all pages without another one
+	mainTemplateFile := filepath.Join(self.Pwd, config.GetValue("mainFile"))
+	template.SetTemplateEngine(config.GetValueOrDefault("templateEngine", template.EngineMarkers))
+	template.SetLayouts(self.Pwd, filepath.Join(self.Pwd, pagesDirectory), mainTemplateFile)
+	template.GetLayout(mainTemplateFile)
 
  // copy all files in resources recursively
  //util.CreateDirIfNotExist(self.Pwd + outputDirectory + "/" + resourcesDirectory)
@@ -85,13 +110,26 @@ func (self *Core) BuildProject() {
      pageGroups,
  )
 
+	// index all pages so links to their source files can be resolved
+	template.SetBrokenLinksMode(config.GetValueOrDefault("brokenLinks", template.BrokenLinksWarn))
+	template.BuildPageIndex(pageGroups)
+	template.RegisterPageUrl(filepath.Join(self.Pwd, config.GetValue("indexFile")), "index.html")
+	template.RegisterPageUrl(filepath.Join(self.Pwd, config.GetValue("404File")), "404.html")
+
+	// convert every page once and extract the excerpts and counts shown
+	// in listings
+	template.SetExcerptWords(getIntConfig("excerptWords", 0))
+	template.SetWordsPerMinute(getIntConfig("wordsPerMinute", 200))
+	template.PreparePages(pageGroups, variables)
+	template.SetPagerAcrossDirectories(config.GetBool("pagerAcrossDirectories", false))
+
 	// for each pagegroup
 	for path, group := range pageGroups {
 		// for each page in pagegroup
 		for _, page := range group.Entries {
 			// exclude link type since it doesnt need to be rendered
 			if "link" != page.Type {
-				pageContent := template.RenderPage(page, mainTemplate, mainTemplateReplacements, variables, pageGroups, group.Ident)
+				pageContent := template.RenderPage(page, template.GetPageLayout(page), variables, pageGroups, group.Ident)
     // Create directory for the page output
     rel := strings.TrimPrefix(path, "/")
     targetDir := filepath.Join(self.Pwd, outputDirectory)
@@ -100,41 +138,239 @@ func (self *Core) BuildProject() {
     }
     util.CreateDirIfNotExist(targetDir)
     util.WriteFile(targetDir, strings.TrimPrefix(page.UrlName, "/")+".html", pageContent, true)
+    self.writeAlternativeFormats(page, targetDir, outputFormats, variables, pageGroups, group.Ident)
 			}
 		}
 	}
 
 	// finally we render index and 404 page
 	// read&render index template
- indexFile := util.ReadFile(filepath.Join(self.Pwd, config.GetValue("indexFile")))
+ indexMeta, indexFile := template.ParseFrontMatter(util.ReadFile(filepath.Join(self.Pwd, config.GetValue("indexFile"))))
 	indexPage := types.Page{
 		Type:     "md",
 		Filename: config.GetValue("indexFile"),
 		Name:     config.GetValue("title"),
-		Path:     "/",
+		Path:     self.Pwd,
 		UrlName:  "index",
 		Content:  indexFile,
+		Meta:     indexMeta,
+		Date:     template.GetPageDate(filepath.Join(self.Pwd, config.GetValue("indexFile")), indexMeta),
 	}
-	indexPageContent := template.RenderPage(indexPage, mainTemplate, mainTemplateReplacements, variables, pageGroups, "")
+	template.PreparePage(&indexPage, variables, pageGroups)
+	indexPageContent := template.RenderPage(indexPage, template.GetPageLayout(indexPage), variables, pageGroups, "")
  util.WriteFile(filepath.Join(self.Pwd, outputDirectory), indexPage.UrlName+".html", indexPageContent, true)
+ self.writeAlternativeFormats(indexPage, filepath.Join(self.Pwd, outputDirectory), outputFormats, variables, pageGroups, "")
 
 	// read&render 404 template
- notFoundFile := util.ReadFile(filepath.Join(self.Pwd, config.GetValue("404File")))
+ notFoundMeta, notFoundFile := template.ParseFrontMatter(util.ReadFile(filepath.Join(self.Pwd, config.GetValue("404File"))))
 	notFoundPage := types.Page{
 		Type:     "md",
 		Filename: config.GetValue("404File"),
 		Name:     config.GetValue("title") + " - 404",
-		Path:     "/",
+		Path:     self.Pwd,
 		UrlName:  "404",
 		Content:  notFoundFile,
+		Meta:     notFoundMeta,
+		Date:     template.GetPageDate(filepath.Join(self.Pwd, config.GetValue("404File")), notFoundMeta),
 	}
-	notFoundPageContent := template.RenderPage(notFoundPage, mainTemplate, mainTemplateReplacements, variables, pageGroups, "")
+	template.PreparePage(&notFoundPage, variables, pageGroups)
+	notFoundPageContent := template.RenderPage(notFoundPage, template.GetPageLayout(notFoundPage), variables, pageGroups, "")
  util.WriteFile(filepath.Join(self.Pwd, outputDirectory), notFoundPage.UrlName+".html", notFoundPageContent, true)
+ self.writeAlternativeFormats(notFoundPage, filepath.Join(self.Pwd, outputDirectory), outputFormats, variables, pageGroups, "")
+
+	// all errors of the build are shown at once
+	if reported := util.GetReportedErrors(); 0 < len(reported) {
+		util.PrintReportedErrors()
+		util.Fail("> Build failed with " + strconv.Itoa(len(reported)) + " errors")
+	}
 
 	elapsed := time.Since(startTime)
 	util.Print("> Builded project in " + strconv.FormatInt(elapsed.Milliseconds(), 10) + " ms")
 }
 
+func (self *Core) ConvertFile() {
+	// read the markdown from the given file or stdin
+	var markdown string
+	if "" == self.Input || "-" == self.Input {
+		data, err := io.ReadAll(os.Stdin)
+		if nil != err {
+			util.Error("Could not read from stdin with error '" + err.Error() + "'")
+		}
+		markdown = string(data)
+	} else {
+		markdown = util.ReadFile(self.resolvePath(self.Input))
+	}
+	source := markdown
+	_, markdown = template.ParseFrontMatter(markdown)
+
+	// a config.json in the working directory is optional and only used
+	// for the converter options
+	config.InitOptional()
+	self.registerBlockRenderers()
+	content := converter.Content{
+		Md:      markdown,
+		Options: self.getConverterOptions(),
+	}
+	if "" != self.Input && "-" != self.Input {
+		content.Options.File = self.resolvePath(self.Input)
+		content.Options.LineOffset = strings.Count(source, "\n") - strings.Count(markdown, "\n")
+	}
+	content.Convert()
+	html := content.Html
+	if reported := util.GetReportedErrors(); 0 < len(reported) {
+		util.PrintReportedErrors()
+		util.Fail("> Conversion failed with " + strconv.Itoa(len(reported)) + " errors")
+	}
+
+	// optionally wrap the html into a template
+	if "" != self.Template {
+		wrapper := util.ReadFile(self.resolvePath(self.Template))
+		replacements, err := template.GetReplacementMarkers(wrapper)
+		if nil != err {
+			util.Error("Getting replacements for template failed with error '" + err.Error() + "'")
+		}
+		for _, replacement := range replacements {
+			if "render" == replacement.Type && "content" == replacement.Value {
+				wrapper = strings.ReplaceAll(wrapper, "{{"+replacement.Target+"}}", html)
+			}
+		}
+		html = wrapper
+	}
+
+	if "" == self.Output {
+		os.Stdout.WriteString(html + "\n")
+		return
+	}
+	output := self.resolvePath(self.Output)
+	util.WriteFile(filepath.Dir(output), filepath.Base(output), html, true)
+}
+
+// setDateOptions applies the date format, locale and timezone of the
+// config, the build date is shown in the timezone as well
+func (self *Core) setDateOptions(buildDate time.Time) {
+	template.SetDateFormat(config.GetValueOrDefault("dateFormat", "date"))
+	locale := config.GetValueOrDefault("dateLocale", "en")
+	if !util.StringInArray(template.GetDateLocales(), locale) {
+		util.Error("Unknown dateLocale '" + locale + "' given. Allowed locales are '" + strings.Join(template.GetDateLocales(), ", ") + "'")
+	}
+	template.SetDateLocale(locale)
+	if timezone := config.GetValueOrDefault("timezone", ""); "" != timezone {
+		location, err := time.LoadLocation(timezone)
+		if nil != err {
+			util.Error("Unknown timezone '" + timezone + "' given with error '" + err.Error() + "'")
+		}
+		template.SetTimezone(location)
+	}
+	template.SetBuildDate(buildDate)
+	template.SetGitDirectory(self.Pwd)
+}
+
+// writeAlternativeFormats writes the gemtext and plain text siblings
+// of a page next to its html file
+func (self *Core) writeAlternativeFormats(
+	page types.Page,
+	targetDir string,
+	formats []string,
+	variables map[string]string,
+	pageGroups map[string]types.Pagegroup,
+	groupIdent string,
+) {
+	for _, format := range formats {
+		content := template.RenderPageFormat(page, format, getIntConfig("textWidth", 72), variables, pageGroups, groupIdent)
+		util.WriteFile(targetDir, strings.TrimPrefix(page.UrlName, "/")+"."+format, content, true)
+	}
+}
+
+// getOutputFormats reads the additional output formats given either as
+// json list or as comma separated string
+func getOutputFormats() []string {
+	var formats []string
+	if !config.GetObject("outputFormats", &formats) {
+		formats = util.Explode(",", config.GetValueOrDefault("outputFormats", ""))
+	}
+	var validFormats []string
+	for _, format := range formats {
+		format = strings.TrimPrefix(strings.TrimSpace(format), ".")
+		if "" == format {
+			continue
+		}
+		if converter.FormatGemtext != format && converter.FormatText != format {
+			util.Error("Unknown output format '" + format + "' given. Allowed formats are 'gmi, txt'")
+		}
+		validFormats = append(validFormats, format)
+	}
+	return validFormats
+}
+
+// getIntConfig reads a numeric config given either as json number or as string
+func getIntConfig(key string, defaultValue int) int {
+	var value int
+	if config.GetObject(key, &value) {
+		return value
+	}
+	value, err := strconv.Atoi(config.GetValueOrDefault(key, strconv.Itoa(defaultValue)))
+	if nil != err {
+		util.Error("Config " + key + " must be a number")
+	}
+	return value
+}
+
+func (self *Core) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(self.Pwd, path)
+}
+
+// getConverterOptions returns the converter options of the config, they
+// are shared by the build and the convert command
+func (self *Core) getConverterOptions() converter.Options {
+	var linkPolicy *types.LinkPolicy
+	config.GetObject("linkPolicy", &linkPolicy)
+	mathRenderer := config.GetValueOrDefault("mathRenderer", converter.MathRendererNone)
+	if !util.StringInArray(converter.MathRenderers, mathRenderer) {
+		util.Error("Unknown mathRenderer '" + mathRenderer + "' given, valid values are '" + strings.Join(converter.MathRenderers, "', '") + "'")
+	}
+	return converter.Options{
+		MathRenderer:     mathRenderer,
+		SmartTypography:  config.GetBool("smartTypography", false),
+		TypographyLocale: config.GetValueOrDefault("typographyLocale", "en"),
+		AutolinkRel:      config.GetValueOrDefault("autolinkRel", ""),
+		AutolinkTarget:   config.GetValueOrDefault("autolinkTarget", ""),
+		Base:             config.GetValueOrDefault("base", ""),
+		LinkPolicy:       linkPolicy,
+	}
+}
+
+// registerBlockRenderers registers the configured code block renderers and
+// returns their languages
+func (self *Core) registerBlockRenderers() []string {
+	var languages []string
+	var renderers map[string]types.BlockRendererConfig
+	if !config.GetObject("blockRenderers", &renderers) {
+		return languages
+	}
+	for lang, renderer := range renderers {
+		languages = append(languages, lang)
+		if "" != renderer.Command {
+			converter.RegisterBlockRenderer(lang, converter.CommandBlockRenderer{
+				Command: renderer.Command,
+				Args:    renderer.Args,
+				Open:    renderer.Open,
+				Close:   renderer.Close,
+			})
+		} else {
+			converter.RegisterBlockRenderer(lang, converter.WrapBlockRenderer{
+				Open:  renderer.Open,
+				Close: renderer.Close,
+				Raw:   renderer.Raw,
+			})
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
 func (self *Core) rBuildPageGroups(pageDirectory string, outputDirectory string, currPath string, pageGroups map[string]types.Pagegroup) {
     // Build paths in a platform-safe way while preserving original semantics
     rel := strings.TrimPrefix(currPath, "/")
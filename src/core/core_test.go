@@ -0,0 +1,64 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/config"
+)
+
+func TestConvertFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		template string
+		want     []string
+	}{
+		{
+			name: "defaults without config",
+			want: []string{"<div>", `a $x$ "q"`},
+		},
+		{
+			name:   "converter settings of the config",
+			config: `{"mathRenderer": "client", "smartTypography": true}`,
+			want:   []string{`a <span class='math inline'>\(x\)</span> “q”`},
+		},
+		{
+			name:     "template wrapping",
+			template: "<main>{{render:content}}</main>{{var:title}}",
+			want:     []string{"<main><div>", "</div></main>{{var:title}}"},
+		},
+	}
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config.Data = make(map[string]string)
+			config.Objects = make(map[string]json.RawMessage)
+			dir := t.TempDir()
+			os.Chdir(dir)
+			os.WriteFile(filepath.Join(dir, "in.md"), []byte(`a $x$ "q"`), 0644)
+			if "" != test.config {
+				os.WriteFile(filepath.Join(dir, "config.json"), []byte(test.config), 0644)
+			}
+			app := Core{Input: "in.md", Output: "out.html", Pwd: dir + "/"}
+			if "" != test.template {
+				os.WriteFile(filepath.Join(dir, "main.html"), []byte(test.template), 0644)
+				app.Template = "main.html"
+			}
+			app.ConvertFile()
+			html, err := os.ReadFile(filepath.Join(dir, "out.html"))
+			if nil != err {
+				t.Fatalf("expected an output file, got error %s", err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(string(html), want) {
+					t.Errorf("expected %q in %q", want, html)
+				}
+			}
+		})
+	}
+}
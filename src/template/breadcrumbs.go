@@ -0,0 +1,100 @@
+package template
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+// breadcrumb is a single step of the trail, directories without a page of
+// their name have no url
+type breadcrumb struct {
+	Name string
+	Url  string
+}
+
+// BuildBreadcrumbs renders the trail from the index page through every
+// directory of the group ident to the current page as used by
+// {{render:breadcrumbs}}. Directories are named after the page of their
+// name in the parent directory if there is one. With the jsonld option a
+// schema.org BreadcrumbList is appended.
+func BuildBreadcrumbs(pageGroups map[string]types.Pagegroup, variables map[string]string, indents int, currPage types.Page, currIdent string, options []string) string {
+	trail := getBreadcrumbs(pageGroups, variables, currPage, currIdent)
+	spacing := strings.Repeat(" ", indents)
+
+	html := "<nav class='breadcrumbs' aria-label='Breadcrumb'>\n" + spacing + "  <ol>"
+	for i, crumb := range trail {
+		if i+1 == len(trail) {
+			html = html + "\n" + spacing + "    <li aria-current='page'>" + crumb.Name + "</li>"
+		} else if "" == crumb.Url {
+			html = html + "\n" + spacing + "    <li><span>" + crumb.Name + "</span></li>"
+		} else {
+			html = html + "\n" + spacing + "    <li><a href='" + crumb.Url + "'>" + crumb.Name + "</a></li>"
+		}
+	}
+	html = html + "\n" + spacing + "  </ol>\n" + spacing + "</nav>"
+
+	if util.StringInArray(options, "jsonld") {
+		html = html + "\n" + spacing + "<script type='application/ld+json'>" + buildBreadcrumbsJsonLd(trail, variables["base"]) + "</script>"
+	}
+	return html
+}
+
+func getBreadcrumbs(pageGroups map[string]types.Pagegroup, variables map[string]string, currPage types.Page, currIdent string) []breadcrumb {
+	trail := []breadcrumb{{Name: variables["title"], Url: "index.html"}}
+	if "" == currIdent {
+		// the index page itself or the 404 page
+		if "index" != currPage.UrlName {
+			trail = append(trail, breadcrumb{Name: currPage.Name, Url: currPage.UrlName + ".html"})
+		}
+		return trail
+	}
+
+	parentIdent := "/"
+	for _, dir := range strings.Split(strings.Trim(currIdent, "/"), "/") {
+		if "" == dir {
+			continue
+		}
+		ident := path.Join(parentIdent, dir)
+		crumb := breadcrumb{Name: dir}
+		for _, page := range pageGroups[parentIdent].Entries {
+			if "link" != page.Type && strings.EqualFold(page.UrlName, dir) {
+				crumb = breadcrumb{Name: page.Name, Url: buildInternalUrl(parentIdent, page)}
+				break
+			}
+		}
+		trail = append(trail, crumb)
+		parentIdent = ident
+	}
+	return append(trail, breadcrumb{Name: currPage.Name, Url: buildInternalUrl(currIdent, currPage)})
+}
+
+// buildBreadcrumbsJsonLd returns the trail as schema.org BreadcrumbList,
+// directories without a page are left out since every item needs an url
+func buildBreadcrumbsJsonLd(trail []breadcrumb, base string) string {
+	var items []map[string]interface{}
+	for _, crumb := range trail {
+		if "" == crumb.Url {
+			continue
+		}
+		items = append(items, map[string]interface{}{
+			"@type":    "ListItem",
+			"position": len(items) + 1,
+			"name":     crumb.Name,
+			"item":     base + crumb.Url,
+		})
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"@context":        "https://schema.org",
+		"@type":           "BreadcrumbList",
+		"itemListElement": items,
+	})
+	if nil != err {
+		util.ReportError(util.SourceError{Message: "Building breadcrumbs json-ld failed with error '" + err.Error() + "'"})
+		return ""
+	}
+	return string(data)
+}
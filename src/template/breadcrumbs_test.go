@@ -0,0 +1,78 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+func TestBuildBreadcrumbs(t *testing.T) {
+	pageGroups := map[string]types.Pagegroup{
+		"/": {Ident: "/", Entries: []types.Page{
+			{Name: "Documentation", UrlName: "Docs", Filename: "2.Docs.md", Type: "md"},
+		}},
+		"/docs/api": {Ident: "/docs/api", Entries: []types.Page{
+			{Name: "Client", UrlName: "Client", Filename: "1.Client.md", Type: "md"},
+		}},
+	}
+	variables := map[string]string{"title": "Site", "base": "https://ex.org/"}
+	tests := []struct {
+		name    string
+		page    types.Page
+		ident   string
+		options []string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:  "nested page",
+			page:  pageGroups["/docs/api"].Entries[0],
+			ident: "/docs/api",
+			want: []string{
+				"<li><a href='index.html'>Site</a></li>",
+				"<li><a href='Docs.html'>Documentation</a></li>",
+				"<li><span>api</span></li>",
+				"<li aria-current='page'>Client</li>",
+			},
+			notWant: []string{"ld+json"},
+		},
+		{
+			name:  "root page",
+			page:  pageGroups["/"].Entries[0],
+			ident: "/",
+			want:  []string{"<li><a href='index.html'>Site</a></li>", "<li aria-current='page'>Documentation</li>"},
+		},
+		{
+			name:  "index page",
+			page:  types.Page{Name: "Site", UrlName: "index"},
+			ident: "",
+			want:  []string{"<ol>\n    <li aria-current='page'>Site</li>\n  </ol>"},
+		},
+		{
+			name:    "json-ld skips directories without page",
+			page:    pageGroups["/docs/api"].Entries[0],
+			ident:   "/docs/api",
+			options: []string{"jsonld"},
+			want: []string{
+				`{"@type":"ListItem","item":"https://ex.org/Docs.html","name":"Documentation","position":2}`,
+				`{"@type":"ListItem","item":"https://ex.org/docs/api/Client.html","name":"Client","position":3}`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			html := BuildBreadcrumbs(pageGroups, variables, 0, test.page, test.ident, test.options)
+			for _, want := range test.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("expected %q in %q", want, html)
+				}
+			}
+			for _, notWant := range test.notWant {
+				if strings.Contains(html, notWant) {
+					t.Errorf("expected no %q in %q", notWant, html)
+				}
+			}
+		})
+	}
+}
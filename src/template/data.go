@@ -0,0 +1,260 @@
+package template
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+var dataExtensions = []string{"json", "yaml", "yml", "csv"}
+
+// siteData holds the content of all data files by their path, the file
+// data/team/members.yaml is available as team.members
+var siteData = make(map[string]interface{})
+
+// LoadData reads all data files of the directory into the site data,
+// replacing the data of a previous load. Malformed files are reported and
+// left out, a missing directory means no data.
+func LoadData(directory string) {
+	siteData = make(map[string]interface{})
+	if _, err := os.Stat(directory); nil != err {
+		return
+	}
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if nil != err {
+			return err
+		}
+		ext := strings.TrimPrefix(filepath.Ext(path), ".")
+		if info.IsDir() || !util.StringInArray(dataExtensions, ext) {
+			return nil
+		}
+		value, err := parseDataFile(path, ext)
+		if nil != err {
+			util.ReportError(err.(util.SourceError))
+			return nil
+		}
+		rel, _ := filepath.Rel(directory, strings.TrimSuffix(path, "."+ext))
+		if err := setDataValue(strings.Split(filepath.ToSlash(rel), "/"), value); nil != err {
+			util.ReportError(util.SourceError{File: path, Message: err.Error()})
+		}
+		return nil
+	})
+	if nil != err {
+		util.Error("Could not read data directory '" + directory + "' with error '" + err.Error() + "'")
+	}
+}
+
+func GetData() map[string]interface{} {
+	return siteData
+}
+
+func parseDataFile(path string, ext string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if nil != err {
+		return nil, util.SourceError{File: path, Message: "Reading data file failed with error '" + err.Error() + "'"}
+	}
+	switch ext {
+	case "json":
+		var value interface{}
+		if err := json.Unmarshal(data, &value); nil != err {
+			sourceErr := util.SourceError{File: path, Message: "Invalid json: " + err.Error()}
+			if syntaxErr, ok := err.(*json.SyntaxError); ok {
+				// the offset is behind the invalid character
+				offset := int(syntaxErr.Offset) - 1
+				if 0 > offset {
+					offset = 0
+				}
+				sourceErr.Line, sourceErr.Column = getSourcePosition(string(data), offset)
+			}
+			return nil, sourceErr
+		}
+		return value, nil
+	case "csv":
+		return parseCsvData(path, data)
+	}
+	value, err := util.ParseYaml(string(data))
+	if nil != err {
+		sourceErr := err.(util.SourceError)
+		sourceErr.File = path
+		return nil, sourceErr
+	}
+	return value, nil
+}
+
+// parseCsvData returns the rows of a csv file with a header line as a list
+// of mappings from the header names to the values
+func parseCsvData(path string, data []byte) (interface{}, error) {
+	records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if nil != err {
+		sourceErr := util.SourceError{File: path, Message: "Invalid csv: " + err.Error()}
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			sourceErr.Message = "Invalid csv: " + parseErr.Err.Error()
+			sourceErr.Line, sourceErr.Column = parseErr.Line, parseErr.Column
+		}
+		return nil, sourceErr
+	}
+	rows := []interface{}{}
+	if 0 == len(records) {
+		return rows, nil
+	}
+	header := records[0]
+	for _, record := range records[1:] {
+		row := make(map[string]interface{})
+		for i, name := range header {
+			row[strings.TrimSpace(name)] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// setDataValue stores the value of a data file at its path, a file and a
+// directory of the same name are merged if both are mappings
+func setDataValue(path []string, value interface{}) error {
+	current := siteData
+	for i, key := range path[:len(path)-1] {
+		next, ok := current[key]
+		if !ok {
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+		mapping, ok := next.(map[string]interface{})
+		if !ok {
+			return errors.New("Data '" + strings.Join(path[:i+1], ".") + "' is defined by more than one data file")
+		}
+		current = mapping
+	}
+	key := path[len(path)-1]
+	existing, ok := current[key]
+	if !ok {
+		current[key] = value
+		return nil
+	}
+	existingMapping, existingOk := existing.(map[string]interface{})
+	valueMapping, valueOk := value.(map[string]interface{})
+	if !existingOk || !valueOk {
+		return errors.New("Data '" + strings.Join(path, ".") + "' is defined by more than one data file")
+	}
+	for name, entry := range valueMapping {
+		if _, ok := existingMapping[name]; ok {
+			return errors.New("Data '" + strings.Join(path, ".") + "." + name + "' is defined by more than one data file")
+		}
+		existingMapping[name] = entry
+	}
+	return nil
+}
+
+// GetDataValue returns the data at a dotted path like team.lead.name,
+// numbers select entries of lists like releases.0.version
+func GetDataValue(path string) (interface{}, bool) {
+	var current interface{} = siteData
+	for _, key := range strings.Split(path, ".") {
+		switch value := current.(type) {
+		case map[string]interface{}:
+			next, ok := value[key]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if nil != err || 0 > index || index >= len(value) {
+				return nil, false
+			}
+			current = value[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// formatDataValue returns the text of a data value, lists of scalars are
+// joined with commas and mappings are written as json
+func formatDataValue(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case bool:
+		return strconv.FormatBool(typed)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case []interface{}:
+		var parts []string
+		for _, entry := range typed {
+			switch entry.(type) {
+			case map[string]interface{}, []interface{}:
+				data, _ := json.Marshal(typed)
+				return string(data)
+			}
+			parts = append(parts, formatDataValue(entry))
+		}
+		return strings.Join(parts, ", ")
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// getDataItems returns the items of an each loop over data. Entries of a
+// list are items with their fields, nested ones like {{item:address.city}},
+// or {{item:value}} for scalars. Mappings are iterated sorted by key with
+// the key as {{item:key}}.
+func getDataItems(marker types.Replacement) []map[string]string {
+	value, ok := GetDataValue(marker.Value)
+	if !ok {
+		reportMarkerError(marker, "Tryied to iterate non existing data '"+marker.Value+"'")
+		return nil
+	}
+	var items []map[string]string
+	switch typed := value.(type) {
+	case []interface{}:
+		for _, entry := range typed {
+			items = append(items, getDataItem(entry))
+		}
+	case map[string]interface{}:
+		var keys []string
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			item := getDataItem(typed[key])
+			item["key"] = key
+			items = append(items, item)
+		}
+	default:
+		reportMarkerError(marker, "Tryied to iterate data '"+marker.Value+"' which is no list or mapping")
+	}
+	return items
+}
+
+func getDataItem(value interface{}) map[string]string {
+	item := make(map[string]string)
+	mapping, ok := value.(map[string]interface{})
+	if !ok {
+		item["value"] = formatDataValue(value)
+		return item
+	}
+	flattenDataItem(item, "", mapping)
+	return item
+}
+
+func flattenDataItem(item map[string]string, prefix string, mapping map[string]interface{}) {
+	for key, value := range mapping {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenDataItem(item, prefix+key+".", nested)
+			continue
+		}
+		item[prefix+key] = formatDataValue(value)
+	}
+}
@@ -0,0 +1,90 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+func setTestData(t *testing.T, files map[string]string) string {
+	directory := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(directory, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); nil != err {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); nil != err {
+			t.Fatal(err)
+		}
+	}
+	LoadData(directory)
+	return directory
+}
+
+func TestRenderData(t *testing.T) {
+	defer LoadData("")
+	setTestData(t, map[string]string{
+		"team.yaml":           "lead:\n  name: Ann\nmembers:\n  - name: Bob\n    address: {city: Berlin}\n  - name: Eve\n    address: {city: Paris}\n",
+		"releases.json":       `[{"version": "1.0", "stable": true}, {"version": "1.1", "stable": false}]`,
+		"products/specs.csv":  "name, price\nA,10\nB,20\n",
+		"products/colors.yml": "[red, green]\n",
+	})
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"yaml value", "{{data:team.lead.name}}", "Ann"},
+		{"json list entry", "{{data:releases.0.version}} {{data:releases.1.stable}}", "1.0 false"},
+		{"list of values", "{{data:products.colors}}", "red, green"},
+		{"each list with nested fields", "{{each:data:team.members}}{{item:name}} {{item:address.city}};{{end}}", "Bob Berlin;Eve Paris;"},
+		{"each csv rows", "{{each:data:products.specs}}{{item:name}}={{item:price}};{{end}}", "A=10;B=20;"},
+		{"each mapping by key", "{{each:data:team.lead}}{{item:key}}:{{item:value}}{{end}}", "name:Ann"},
+		{"if data", "{{if:data:team.lead}}yes{{endif}}{{if:data:team.nope}}no{{endif}}", "yes"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodes, err := ParseTemplate(test.template)
+			if nil != err {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if got := RenderTemplate(nodes, RenderContext{}); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadDataErrors(t *testing.T) {
+	defer LoadData("")
+	defer util.ResetReportedErrors()
+	util.ResetReportedErrors()
+	directory := setTestData(t, map[string]string{
+		"broken.json": "{\n  \"a\": 1,\n}",
+		"broken.yaml": "base: &base\n",
+		"broken.csv":  "a,b\n1,2,3\n",
+		"team.yaml":   "name: x\n",
+		"team.json":   `{"name": "y"}`,
+		"fine.json":   `{"a": 1}`,
+	})
+	if _, ok := GetDataValue("fine.a"); !ok {
+		t.Errorf("expected the valid data to be loaded")
+	}
+	var got []string
+	for _, err := range util.GetReportedErrors() {
+		got = append(got, err.Error())
+	}
+	for _, want := range []string{
+		filepath.Join(directory, "broken.json") + ":3:1: Invalid json",
+		filepath.Join(directory, "broken.yaml") + ":1:1: Invalid yaml: anchors and aliases are not supported",
+		filepath.Join(directory, "broken.csv") + ":2:",
+		"Data 'team.name' is defined by more than one data file",
+	} {
+		if !strings.Contains(strings.Join(got, "\n"), want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+}
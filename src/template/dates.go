@@ -0,0 +1,223 @@
+package template
+
+import (
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// named date formats usable in place of a go time layout, the rfc
+// formats are meant for machines and never use localized names
+var dateFormats = map[string]string{
+	"date":     "2006-01-02",
+	"datetime": "2006-01-02 15:04",
+	"rfc3339":  time.RFC3339,
+	"rfc1123":  time.RFC1123Z,
+}
+
+// dateLocale holds the names of months and weekdays of a language plus
+// its long and short date layouts
+type dateLocale struct {
+	Months      [12]string
+	ShortMonths [12]string
+	Days        [7]string
+	ShortDays   [7]string
+	Long        string
+	Short       string
+}
+
+var dateLocales = map[string]dateLocale{
+	"en": {
+		Months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		Days:        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		ShortDays:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		Long:        "January 2, 2006",
+		Short:       "Jan 2, 2006",
+	},
+	"de": {
+		Months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		ShortMonths: [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		Days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		ShortDays:   [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		Long:        "2. January 2006",
+		Short:       "2. Jan 2006",
+	},
+	"fr": {
+		Months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		ShortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		Days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		ShortDays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		Long:        "2 January 2006",
+		Short:       "2 Jan 2006",
+	},
+	"es": {
+		Months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		ShortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		Days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		ShortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		Long:        "2 de January de 2006",
+		Short:       "2 Jan 2006",
+	},
+	"it": {
+		Months:      [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		ShortMonths: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		Days:        [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		ShortDays:   [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+		Long:        "2 January 2006",
+		Short:       "2 Jan 2006",
+	},
+	"nl": {
+		Months:      [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		ShortMonths: [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		Days:        [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		ShortDays:   [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+		Long:        "2 January 2006",
+		Short:       "2 Jan 2006",
+	},
+}
+
+// BuildDateVariable is the variable holding the time of the build
+const BuildDateVariable = "build.date"
+
+// placeholders for names in a layout, they contain no layout tokens
+const monthPlaceholder = "\x01"
+const shortMonthPlaceholder = "\x02"
+const dayPlaceholder = "\x03"
+const shortDayPlaceholder = "\x04"
+
+// gitDateMarker starts the lines of the git log holding a commit date, a
+// nul byte which no file name contains
+const gitDateMarker = "\x00"
+
+var dateFormat = "date"
+var dateLanguage = "en"
+var dateLocation = time.Local
+var buildDate = time.Now()
+
+// gitDates maps the files below the git directory to the time of their
+// last commit, read once when the first page without a date is found
+var gitDirectory = ""
+var gitDates map[string]time.Time
+
+// SetDateFormat sets the format of dates without an explicit one, a
+// named format or a go time layout
+func SetDateFormat(format string) {
+	dateFormat = format
+}
+
+func GetDateLocales() []string {
+	var locales []string
+	for name := range dateLocales {
+		locales = append(locales, name)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+func SetDateLocale(locale string) {
+	dateLanguage = locale
+}
+
+// SetTimezone sets the location dates are shown in, front matter dates
+// without a zone are read in it as well
+func SetTimezone(location *time.Location) {
+	dateLocation = location
+}
+
+func SetBuildDate(date time.Time) {
+	buildDate = date
+}
+
+// SetGitDirectory sets the directory whose git history dates the pages
+// without a front matter date
+func SetGitDirectory(directory string) {
+	gitDirectory = directory
+	gitDates = nil
+}
+
+// FormatDate formats a date in the configured timezone with a named format
+// like long or a go time layout like 2006-01-02, the default format if
+// empty. Month and weekday names are taken from the date locale.
+func FormatDate(date time.Time, format string) string {
+	if date.IsZero() {
+		return ""
+	}
+	if "" == format {
+		format = dateFormat
+	}
+	date = date.In(dateLocation)
+	locale, ok := dateLocales[dateLanguage]
+	if !ok {
+		locale = dateLocales["en"]
+	}
+	switch format {
+	case "long":
+		format = locale.Long
+	case "short":
+		format = locale.Short
+	default:
+		if layout, ok := dateFormats[format]; ok {
+			return date.Format(layout)
+		}
+	}
+
+	// the longer names come first since they contain the short ones
+	layout := strings.NewReplacer(
+		"January", monthPlaceholder,
+		"Monday", dayPlaceholder,
+		"Jan", shortMonthPlaceholder,
+		"Mon", shortDayPlaceholder,
+	).Replace(format)
+	return strings.NewReplacer(
+		monthPlaceholder, locale.Months[date.Month()-1],
+		shortMonthPlaceholder, locale.ShortMonths[date.Month()-1],
+		dayPlaceholder, locale.Days[date.Weekday()],
+		shortDayPlaceholder, locale.ShortDays[date.Weekday()],
+	).Replace(date.Format(layout))
+}
+
+// getGitDate returns the time of the last commit of a file, false if the
+// file isn't tracked by git or git isn't available
+func getGitDate(fullPath string) (time.Time, bool) {
+	if nil == gitDates {
+		gitDates = loadGitDates(gitDirectory)
+	}
+	date, ok := gitDates[filepath.Clean(fullPath)]
+	return date, ok
+}
+
+// loadGitDates reads the time of the last commit of every file below the
+// directory with a single git call, the log is newest first
+func loadGitDates(directory string) map[string]time.Time {
+	dates := make(map[string]time.Time)
+	if "" == directory {
+		return dates
+	}
+	cmd := exec.Command("git", "-c", "core.quotePath=false", "log", "--relative", "--format=%x00%cI", "--name-only", "--", ".")
+	cmd.Dir = directory
+	out, err := cmd.Output()
+	if nil != err {
+		return dates
+	}
+	var date time.Time
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, gitDateMarker) {
+			date, err = time.Parse(time.RFC3339, strings.TrimPrefix(line, gitDateMarker))
+			if nil != err {
+				date = time.Time{}
+			}
+			continue
+		}
+		if "" == line || date.IsZero() {
+			continue
+		}
+		path := filepath.Join(directory, filepath.FromSlash(line))
+		if _, ok := dates[path]; !ok {
+			dates[path] = date
+		}
+	}
+	return dates
+}
@@ -0,0 +1,154 @@
+package template
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+func TestFormatDate(t *testing.T) {
+	defer SetDateFormat("date")
+	defer SetDateLocale("en")
+	defer SetTimezone(time.Local)
+	SetTimezone(time.UTC)
+	date := time.Date(2024, 3, 5, 22, 30, 0, 0, time.UTC)
+	tests := []struct {
+		locale string
+		format string
+		want   string
+	}{
+		{"en", "", "2024-03-05"},
+		{"en", "long", "March 5, 2024"},
+		{"en", "short", "Mar 5, 2024"},
+		{"en", "rfc3339", "2024-03-05T22:30:00Z"},
+		{"en", "Monday, 02.01.2006 15:04", "Tuesday, 05.03.2024 22:30"},
+		{"de", "long", "5. März 2024"},
+		{"de", "Mon, 2. Jan", "Di., 5. März"},
+		{"fr", "long", "5 mars 2024"},
+		{"fr", "Monday 2 January", "mardi 5 mars"},
+		{"es", "long", "5 de marzo de 2024"},
+		{"it", "short", "5 mar 2024"},
+		{"nl", "Monday 2 January 2006", "dinsdag 5 maart 2024"},
+		{"de", "rfc1123", "Tue, 05 Mar 2024 22:30:00 +0000"},
+		{"nope", "long", "March 5, 2024"},
+	}
+	for _, test := range tests {
+		t.Run(test.locale+" "+test.format, func(t *testing.T) {
+			SetDateLocale(test.locale)
+			if got := FormatDate(date, test.format); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+
+	SetDateLocale("en")
+	SetDateFormat("long")
+	if got := FormatDate(date, ""); "March 5, 2024" != got {
+		t.Errorf("expected the configured format but got %q", got)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if nil != err {
+		t.Skip("timezone data not available")
+	}
+	SetTimezone(berlin)
+	if got := FormatDate(date, "2006-01-02 15:04"); "2024-03-05 23:30" != got {
+		t.Errorf("expected the date in the timezone but got %q", got)
+	}
+	if got := FormatDate(time.Time{}, ""); "" != got {
+		t.Errorf("expected no date but got %q", got)
+	}
+}
+
+func TestRenderDateMarkers(t *testing.T) {
+	defer SetDateLocale("en")
+	defer SetTimezone(time.Local)
+	defer SetBuildDate(time.Now())
+	SetTimezone(time.UTC)
+	SetDateLocale("de")
+	SetBuildDate(time.Date(2025, 12, 24, 8, 0, 0, 0, time.UTC))
+	page := types.Page{Name: "Post", Date: time.Date(2024, 1, 2, 9, 5, 0, 0, time.UTC)}
+	nodes, err := ParseTemplate("{{page:date}}|{{page:date:long}}|{{page:date:15:04}}|{{var:build.date:January 2006}}")
+	if nil != err {
+		t.Fatalf("unexpected error %s", err)
+	}
+	want := "2024-01-02|2. Januar 2024|09:05|Dezember 2025"
+	if got := RenderTemplate(nodes, RenderContext{Page: page}); want != got {
+		t.Errorf("expected %q but got %q", want, got)
+	}
+}
+
+func TestGetPageDate(t *testing.T) {
+	defer SetGitDirectory("")
+	defer SetTimezone(time.Local)
+	SetTimezone(time.UTC)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "1.Page.md")
+	if err := os.WriteFile(file, []byte("x"), 0644); nil != err {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(file, modTime, modTime); nil != err {
+		t.Fatal(err)
+	}
+	SetGitDirectory(dir)
+
+	if got := GetPageDate(file, map[string]string{"date": "2024-01-02"}); !time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC).Equal(got) {
+		t.Errorf("expected the front matter date but got %s", got)
+	}
+	if got := GetPageDate(file, map[string]string{}); !modTime.Equal(got) {
+		t.Errorf("expected the modification time but got %s", got)
+	}
+}
+
+func TestLoadGitDates(t *testing.T) {
+	if _, err := exec.LookPath("git"); nil != err {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	git := func(env []string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); nil != err {
+			t.Fatalf("git %v failed with %s: %s", args, err, out)
+		}
+	}
+	commit := func(date string, files ...string) {
+		for _, name := range files {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); nil != err {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(date), 0644); nil != err {
+				t.Fatal(err)
+			}
+		}
+		git(nil, "add", "-A")
+		git([]string{"GIT_COMMITTER_DATE=" + date, "GIT_AUTHOR_DATE=" + date}, "-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "-m", date)
+	}
+	git(nil, "init", "-q")
+	commit("2024-01-01T10:00:00Z", "pages/1.Old.md", "pages/2.New.md")
+	commit("2024-02-01T10:00:00Z", "pages/2.New.md", "pages/docs/1.Ü.md")
+
+	dates := loadGitDates(filepath.Join(dir, "pages"))
+	tests := map[string]time.Time{
+		"1.Old.md":    time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		"2.New.md":    time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC),
+		"docs/1.Ü.md": time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC),
+	}
+	if len(tests) != len(dates) {
+		t.Errorf("expected %d dates but got %v", len(tests), dates)
+	}
+	for name, want := range tests {
+		if got, ok := dates[filepath.Join(dir, "pages", filepath.FromSlash(name))]; !ok || !want.Equal(got) {
+			t.Errorf("expected %s for %q but got %s", want, name, got)
+		}
+	}
+	if 0 != len(loadGitDates(t.TempDir())) {
+		t.Errorf("expected no dates outside of a repository")
+	}
+}
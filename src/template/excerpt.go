@@ -0,0 +1,89 @@
+package template
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+const ExcerptSeparator = "<!--more-->"
+
+const firstParagraphRxp = `(?s)<p>(.*?)</p>`
+const excerptHeadingsRxp = `(?s)<h[1-6][^>]*>.*?</h[1-6]>`
+const excerptSeparatorRxp = `\s*<p>\s*<!--more-->\s*</p>|<!--more-->(?:<br>)?`
+const excerptTagsRxp = `<[^>]*>`
+
+var excerptWords = 0
+
+// SetExcerptWords limits generated excerpts to the given amount of words,
+// 0 keeps the whole first paragraph
+func SetExcerptWords(words int) {
+	excerptWords = words
+}
+
+// PreparePages prepares every page in the given pagegroups
+func PreparePages(pageGroups map[string]types.Pagegroup, variables map[string]string) {
+	for ident, group := range pageGroups {
+		for i := range group.Entries {
+			PreparePage(&group.Entries[i], variables, pageGroups)
+		}
+		pageGroups[ident] = group
+	}
+}
+
+// PreparePage converts the page to html once and sets its excerpt and
+// counts, the rendering of the page and its other formats reuse the html
+func PreparePage(page *types.Page, variables map[string]string, pageGroups map[string]types.Pagegroup) {
+	if "link" == page.Type {
+		return
+	}
+	page.Html = renderPageContent(*page, true)
+	page.Excerpt = GetExcerpt(*page, variables, pageGroups)
+	SetPageStats(page)
+}
+
+// GetExcerpt returns the text before an explicit <!--more--> separator or
+// else the first paragraph of the converted page, limited to the configured
+// amount of words. Var markers and if and each constructs are rendered, all
+// other markers, headings and tags are stripped.
+func GetExcerpt(page types.Page, variables map[string]string, pageGroups map[string]types.Pagegroup) string {
+	if "link" == page.Type {
+		return ""
+	}
+	content := getPageHtml(page, false)
+	explicit := false
+	if idx := strings.Index(content, ExcerptSeparator); -1 != idx {
+		content = content[:idx]
+		explicit = true
+	}
+	content = renderVarMarkers(page, content, variables, pageGroups, "")
+	if explicit {
+		// the page title is shown by the listing itself
+		content = regexp.MustCompile(excerptHeadingsRxp).ReplaceAllString(content, "")
+	} else {
+		tmp := regexp.MustCompile(firstParagraphRxp)
+		match := tmp.FindStringSubmatch(content)
+		if nil == match {
+			return ""
+		}
+		content = match[1]
+	}
+
+	content = strings.ReplaceAll(content, "<br>", " ")
+	words := strings.Fields(regexp.MustCompile(excerptTagsRxp).ReplaceAllString(content, ""))
+	if 0 < excerptWords && len(words) > excerptWords {
+		return strings.Join(words[:excerptWords], " ") + " …"
+	}
+	return strings.Join(words, " ")
+}
+
+// removeExcerptSeparator drops the first excerpt separator together with the
+// paragraph the converter wrapped it in
+func removeExcerptSeparator(content string) string {
+	tmp := regexp.MustCompile(excerptSeparatorRxp)
+	if loc := tmp.FindStringIndex(content); nil != loc {
+		return content[:loc[0]] + content[loc[1]:]
+	}
+	return content
+}
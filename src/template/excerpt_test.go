@@ -0,0 +1,70 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+func TestGetExcerpt(t *testing.T) {
+	setTestShortcodes()
+	defer SetExcerptWords(0)
+	variables := map[string]string{"who": "World"}
+	tests := []struct {
+		name  string
+		page  types.Page
+		words int
+		want  string
+	}{
+		{"first paragraph", types.Page{Type: "md", Content: "# Title\n\nHello **bold**.\nnext line\n\nsecond"}, 0, "Hello bold. next line"},
+		{"explicit separator", types.Page{Type: "md", Content: "# Title\n\nfirst\n\nsecond\n\n<!--more-->\n\nthird"}, 0, "first second"},
+		{"word limit", types.Page{Type: "md", Content: "one two three four"}, 2, "one two …"},
+		{"var markers are replaced", types.Page{Type: "md", Content: "Hello {{var:who}} {{nav:main}}"}, 0, "Hello World"},
+		{"shortcodes are rendered", types.Page{Type: "md", Content: `a {{< note title="b" >}} c`}, 0, "a b c"},
+		{"html page", types.Page{Type: "html", Content: "<h1>T</h1><p>x &amp; <i>y</i></p>"}, 0, "x &amp; y"},
+		{"link page", types.Page{Type: "link", Content: "https://x.org"}, 0, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SetExcerptWords(test.words)
+			if got := GetExcerpt(test.page, variables, nil); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestPreparePage(t *testing.T) {
+	page := types.Page{Name: "Post", Type: "md", UrlName: "Post", Content: "intro\n\n<!--more-->\n\nrest"}
+	PreparePage(&page, nil, nil)
+	if "intro" != page.Excerpt {
+		t.Errorf("expected excerpt %q but got %q", "intro", page.Excerpt)
+	}
+	if !strings.Contains(page.Html, ExcerptSeparator) {
+		t.Errorf("expected the separator in the cached html %q", page.Html)
+	}
+
+	// rendering reuses the cached html and drops the separator
+	page.Html = strings.Replace(page.Html, "rest", "cached", 1)
+	nodes, _ := ParseTemplate("{{render:content}}")
+	layout := Layout{Nodes: nodes}
+	rendered := RenderPage(page, layout, nil, nil, "/")
+	if strings.Contains(rendered, ExcerptSeparator) || strings.Count(rendered, "<p>") != 2 {
+		t.Errorf("expected the separator paragraph to be removed in %q", rendered)
+	}
+	if !strings.Contains(rendered, "cached") {
+		t.Errorf("expected the cached html to be rendered in %q", rendered)
+	}
+}
+
+func TestBuildPageGroupNavExcerpts(t *testing.T) {
+	pagegroup := types.Pagegroup{Ident: "/blog", Entries: []types.Page{{Name: "Post", UrlName: "Post", Type: "md", Excerpt: "intro"}}}
+	want := "<a href='blog/Post.html'>Post</a><p class='excerpt'>intro</p></li>"
+	if nav := BuildPageGroupNav(pagegroup, 0, types.Page{}, "", []string{"asc", "excerpt"}); !strings.Contains(nav, want) {
+		t.Errorf("expected %q in %q", want, nav)
+	}
+	if nav := BuildPageGroupNav(pagegroup, 0, types.Page{}, "", nil); strings.Contains(nav, "excerpt") {
+		t.Errorf("expected no excerpt in %q", nav)
+	}
+}
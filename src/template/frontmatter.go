@@ -0,0 +1,52 @@
+package template
+
+import (
+	"strings"
+	"time"
+)
+
+const frontMatterDelimiter = "---"
+
+// date layouts accepted for the date key of the front matter
+var frontMatterDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// ParseFrontMatter splits a leading front matter block of "key: value"
+// lines enclosed by "---" lines from the content. Content without front
+// matter is returned unchanged with an empty meta map.
+func ParseFrontMatter(content string) (map[string]string, string) {
+	meta := make(map[string]string)
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, frontMatterDelimiter+"\n") {
+		return meta, content
+	}
+	lines := strings.Split(normalized, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if frontMatterDelimiter == line {
+			return meta, strings.TrimLeft(strings.Join(lines[i+1:], "\n"), "\n")
+		}
+		if "" == line || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if 2 != len(parts) {
+			// not a front matter block after all
+			return make(map[string]string), content
+		}
+		meta[strings.TrimSpace(parts[0])] = strings.Trim(strings.TrimSpace(parts[1]), "\"'")
+	}
+	// unterminated blocks are content
+	return make(map[string]string), content
+}
+
+// parseFrontMatterDate parses the date of the front matter, dates without
+// a zone are in the configured timezone
+func parseFrontMatterDate(value string) (time.Time, bool) {
+	for _, layout := range frontMatterDateLayouts {
+		date, err := time.ParseInLocation(layout, value, dateLocation)
+		if nil == err {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
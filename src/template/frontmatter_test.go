@@ -0,0 +1,58 @@
+package template
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantMeta    map[string]string
+		wantContent string
+	}{
+		{"none", "# Title", map[string]string{}, "# Title"},
+		{"meta", "---\ntitle: \"Hello: World\"\n# comment\ndate: 2024-01-02\n---\n\n# Title", map[string]string{"title": "Hello: World", "date": "2024-01-02"}, "# Title"},
+		{"windows line endings", "---\r\nkey: value\r\n---\r\nbody", map[string]string{"key": "value"}, "body"},
+		{"unterminated", "---\nkey: value\nbody", map[string]string{}, "---\nkey: value\nbody"},
+		{"not key value", "---\njust a line\n---\nbody", map[string]string{}, "---\njust a line\n---\nbody"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			meta, content := ParseFrontMatter(test.content)
+			if test.wantContent != content {
+				t.Errorf("expected content %q but got %q", test.wantContent, content)
+			}
+			if len(test.wantMeta) != len(meta) {
+				t.Fatalf("expected meta %q but got %q", test.wantMeta, meta)
+			}
+			for key, value := range test.wantMeta {
+				if value != meta[key] {
+					t.Errorf("expected %s to be %q but got %q", key, value, meta[key])
+				}
+			}
+		})
+	}
+}
+
+func TestParseFrontMatterDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+		ok    bool
+	}{
+		{"2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), true},
+		{"2024-01-02 15:04", time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC), true},
+		{"2024-01-02T15:04:05Z", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), true},
+		{"yesterday", time.Time{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, ok := parseFrontMatterDate(test.value)
+			if test.ok != ok || !test.want.Equal(got) {
+				t.Errorf("expected %v %v but got %v %v", test.want, test.ok, got, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+// template engines rendering the mainFile and layouts
+const EngineMarkers = "markers"
+const EngineGo = "go"
+
+var templateEngine = EngineMarkers
+
+// position and message of html/template parse and execution errors
+const goTemplateErrorRxp = `^template: ([^:]+):(\d+):(\d*):? ?(.*)$`
+
+// SetTemplateEngine selects the engine layouts are rendered with, page
+// content always uses the marker engine
+func SetTemplateEngine(engine string) {
+	if EngineMarkers != engine && EngineGo != engine {
+		util.Error("Unknown templateEngine '" + engine + "', valid engines are '" + EngineMarkers + "' and '" + EngineGo + "'")
+	}
+	templateEngine = engine
+	layouts = make(map[string]Layout)
+}
+
+// GoSite holds the site wide data of the go template engine
+type GoSite struct {
+	Title string
+	Base  string
+}
+
+// GoPage is a page as seen by the go template engine
+type GoPage struct {
+	Name        string
+	Url         string
+	Type        string
+	Excerpt     string
+	Date        time.Time
+	Sequence    int
+	Meta        map[string]string
+	WordCount   int
+	CharCount   int
+	ReadingTime int
+}
+
+// GoTemplateData is the data a layout is executed with by the go template
+// engine. PageGroups holds the pages of every directory by ident.
+type GoTemplateData struct {
+	Site       GoSite
+	Page       GoPage
+	PageGroups map[string][]GoPage
+	Vars       map[string]string
+	Data       map[string]interface{}
+	Content    htmltemplate.HTML
+}
+
+const goTemplateCallRxp = `\{\{-?\s*template\s+"([^"]+)"`
+
+// parseGoLayout parses a layout with html/template. Files of the partials
+// directory can be used with {{template "header.html" .}}, only the ones
+// referenced are parsed since the directory may also hold marker partials.
+func parseGoLayout(file string) *htmltemplate.Template {
+	// on errors the pages are still built with their bare content
+	fallback := htmltemplate.Must(htmltemplate.New(filepath.Base(file)).Parse("{{.Content}}"))
+	data, err := os.ReadFile(file)
+	if nil != err {
+		util.ReportError(util.SourceError{File: file, Message: "Could not read layout with error '" + err.Error() + "'"})
+		return fallback
+	}
+	tmpl, err := htmltemplate.New(filepath.Base(file)).Funcs(getGoTemplateFuncs(RenderContext{})).Parse(string(data))
+	if nil != err {
+		util.ReportError(newGoTemplateError(err, file))
+		return fallback
+	}
+	pending := []string{string(data)}
+	for 0 < len(pending) {
+		content := pending[0]
+		pending = pending[1:]
+		for _, match := range regexp.MustCompile(goTemplateCallRxp).FindAllStringSubmatch(content, -1) {
+			name := match[1]
+			if nil != tmpl.Lookup(name) {
+				continue
+			}
+			partialFile := filepath.Join(partialsPath, name)
+			if _, err := os.Stat(partialFile); nil != err {
+				// may be defined in the layout itself
+				continue
+			}
+			partialData, err := os.ReadFile(partialFile)
+			if nil != err {
+				util.ReportError(util.SourceError{File: partialFile, Message: "Could not read partial with error '" + err.Error() + "'"})
+				continue
+			}
+			partial := string(partialData)
+			_, err = tmpl.New(name).Parse(partial)
+			if nil != err {
+				util.ReportError(newGoTemplateError(err, partialFile))
+				continue
+			}
+			pending = append(pending, partial)
+		}
+	}
+	return tmpl
+}
+
+// renderGoLayout executes a go layout for the page of the context. The
+// parsed layout is cloned so the functions can be bound to the page.
+func renderGoLayout(layout Layout, context RenderContext) string {
+	tmpl, err := layout.GoTemplate.Clone()
+	if nil != err {
+		util.ReportError(util.SourceError{File: layout.File, Message: "Cloning layout failed with error '" + err.Error() + "'"})
+		return context.Content
+	}
+	tmpl.Funcs(getGoTemplateFuncs(context))
+
+	var out bytes.Buffer
+	err = tmpl.Execute(&out, getGoTemplateData(context))
+	if nil != err {
+		util.ReportError(newGoTemplateError(err, layout.File))
+	}
+	return out.String()
+}
+
+// newGoTemplateError takes the position out of an html/template error like
+// "template: main.html:3:12: executing ..." of the given file. Errors of
+// partials name the partial, they are reported without a position.
+func newGoTemplateError(err error, file string) util.SourceError {
+	match := regexp.MustCompile(goTemplateErrorRxp).FindStringSubmatch(err.Error())
+	if nil == match {
+		return util.SourceError{File: file, Message: err.Error()}
+	}
+	if match[1] != filepath.Base(file) {
+		return util.SourceError{File: file, Message: "in '" + match[1] + "': " + match[4]}
+	}
+	line, _ := strconv.Atoi(match[2])
+	column, _ := strconv.Atoi(match[3])
+	return util.SourceError{File: file, Line: line, Column: column, Message: match[4]}
+}
+
+func getGoTemplateData(context RenderContext) GoTemplateData {
+	data := GoTemplateData{
+		Site: GoSite{
+			Title: context.Variables["title"],
+			Base:  context.Variables["base"],
+		},
+		Page:       getGoPage(context.Page, context.GroupIdent),
+		PageGroups: make(map[string][]GoPage),
+		Vars:       context.Variables,
+		Data:       GetData(),
+		// the content is rendered by gomcmf and trusted
+		Content: htmltemplate.HTML(context.Content),
+	}
+	for ident, group := range context.PageGroups {
+		for _, page := range group.Entries {
+			data.PageGroups[ident] = append(data.PageGroups[ident], getGoPage(page, ident))
+		}
+	}
+	return data
+}
+
+func getGoPage(page types.Page, ident string) GoPage {
+	goPage := GoPage{
+		Name:        page.Name,
+		Type:        page.Type,
+		Excerpt:     page.Excerpt,
+		Date:        page.Date,
+		Sequence:    page.Sequence,
+		Meta:        page.Meta,
+		WordCount:   page.WordCount,
+		CharCount:   page.CharCount,
+		ReadingTime: GetReadingTime(page),
+	}
+	if "link" == page.Type {
+		goPage.Url = strings.TrimSpace(page.Content)
+	} else if "" == ident {
+		goPage.Url = page.UrlName + ".html"
+	} else {
+		goPage.Url = buildInternalUrl(ident, page)
+	}
+	return goPage
+}
+
+// getGoTemplateFuncs returns the functions available in go layouts, the
+// nav builders are bound to the page of the context
+func getGoTemplateFuncs(context RenderContext) htmltemplate.FuncMap {
+	return htmltemplate.FuncMap{
+		"absURL": func(url string) string {
+			return context.Variables["base"] + strings.TrimPrefix(url, "/")
+		},
+		"relURL": func(url string) string {
+			return strings.TrimPrefix(url, "/")
+		},
+		"formatDate": func(format string, date time.Time) string {
+			return FormatDate(date, format)
+		},
+		"nav": func(ident string, options ...string) (htmltemplate.HTML, error) {
+			group, ok := context.PageGroups[ident]
+			if !ok {
+				return "", errors.New("tryied to render non existing pagegroup '" + ident + "'")
+			}
+			return htmltemplate.HTML(BuildPageGroupNav(group, 0, context.Page, context.GroupIdent, options)), nil
+		},
+		"navTree": func(options ...string) htmltemplate.HTML {
+			return htmltemplate.HTML(BuildNavTree(context.PageGroups, 0, context.Page, context.GroupIdent, options))
+		},
+		"breadcrumbs": func(options ...string) htmltemplate.HTML {
+			return htmltemplate.HTML(BuildBreadcrumbs(context.PageGroups, context.Variables, 0, context.Page, context.GroupIdent, options))
+		},
+		"prev": func() htmltemplate.HTML {
+			return htmltemplate.HTML(BuildPagerLink(context.PageGroups, context.Page, context.GroupIdent, "prev"))
+		},
+		"next": func() htmltemplate.HTML {
+			return htmltemplate.HTML(BuildPagerLink(context.PageGroups, context.Page, context.GroupIdent, "next"))
+		},
+	}
+}
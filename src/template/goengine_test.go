@@ -0,0 +1,66 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+func TestRenderGoLayout(t *testing.T) {
+	project := t.TempDir()
+	files := map[string]string{
+		"main.html": `<title>{{.Site.Title}} - {{.Page.Name}}</title>{{template "header.html" .}}` +
+			`<p>{{index .Page.Meta "subtitle"}}</p>{{.Content}}` +
+			`{{range index .PageGroups "/blog"}}[{{.Name}} {{.Url}}]{{end}}` +
+			`{{nav "/blog" "desc"}}{{absURL "/x.html"}} {{formatDate "2006-01-02" .Page.Date}}`,
+		"partials/header.html": `<header>{{.Vars.title}}</header>`,
+	}
+	for name, content := range files {
+		path := filepath.Join(project, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); nil != err {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); nil != err {
+			t.Fatal(err)
+		}
+	}
+	SetPartialsPath(project, "partials")
+	SetLayouts(project, filepath.Join(project, "pages"), filepath.Join(project, "main.html"))
+	SetTemplateEngine(EngineGo)
+	defer SetTemplateEngine(EngineMarkers)
+
+	pageGroups := map[string]types.Pagegroup{
+		"/blog": {Ident: "/blog", Entries: []types.Page{
+			{Name: "One", UrlName: "One", Filename: "1.One.md", Type: "md", Sequence: 1},
+			{Name: "Two", UrlName: "Two", Filename: "2.Two.md", Type: "md", Sequence: 2},
+		}},
+	}
+	page := pageGroups["/blog"].Entries[0]
+	page.Meta = map[string]string{"subtitle": "<b>sub</b>"}
+	page.Date = time.Date(2024, 1, 2, 12, 0, 0, 0, time.Local)
+	got := RenderLayout(GetPageLayout(page), RenderContext{
+		Variables:  map[string]string{"title": "Site", "base": "https://ex.org/"},
+		PageGroups: pageGroups,
+		Page:       page,
+		GroupIdent: "/blog",
+		Content:    "<div>content</div>",
+	})
+	for _, want := range []string{
+		"<title>Site - One</title>",
+		"<header>Site</header>",
+		"<p>&lt;b&gt;sub&lt;/b&gt;</p>",
+		"<div>content</div>",
+		"[One blog/One.html][Two blog/Two.html]",
+		"<li><a href='blog/Two.html'>Two</a></li>",
+		"<li class='active'><a href='blog/One.html'>One</a></li>",
+		"https://ex.org/x.html 2024-01-02",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+	}
+}
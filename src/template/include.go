@@ -0,0 +1,148 @@
+package template
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/voodooEntity/gomcmf/src/converter"
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+// MaxIncludeDepth limits how deep partials may include further partials
+const MaxIncludeDepth = 10
+
+const includeRxp = `\{\{include:([^}]+)\}\}`
+const includeParagraphRxp = `<p>\s*(\{\{include:[^}]+\}\})\s*</p>`
+
+var projectPath = ""
+var partialsPath = "partials"
+var partials = make(map[string]string)
+
+// SetPartialsPath sets the project directory and the partials directory
+// within it {{include:...}} markers are resolved in
+func SetPartialsPath(project string, path string) {
+	projectPath = project
+	partialsPath = filepath.Join(project, path)
+	partials = make(map[string]string)
+}
+
+// ExpandIncludes replaces all {{include:file}} markers with the content of
+// the partial file, recursively up to MaxIncludeDepth. Every line of an
+// included partial is indented like the marker. Partials ending in .md are
+// converted to html first. Includes in code blocks of markdown are kept.
+func ExpandIncludes(content string, markdown bool) (string, error) {
+	return expandIncludes(content, []string{}, markdown)
+}
+
+func expandIncludes(content string, stack []string, markdown bool) (string, error) {
+	if !strings.Contains(content, "{{include:") {
+		return content, nil
+	}
+	tmp := regexp.MustCompile(includeRxp)
+	var codeRegions [][]int
+	if markdown {
+		codeRegions = getCodeRegions(content)
+	}
+	var out strings.Builder
+	last := 0
+	for _, loc := range tmp.FindAllStringSubmatchIndex(content, -1) {
+		// includes in code blocks and escaped ones are kept
+		if -1 != getRegionEnd(codeRegions, loc[0]) || strings.HasSuffix(content[:loc[0]+2], escapedOpenDelimiter) || strings.HasSuffix(content[:loc[0]+2], rawOpenDelimiter) {
+			continue
+		}
+		partial, err := loadPartial(strings.TrimSpace(content[loc[2]:loc[3]]), stack)
+		if nil != err {
+			return "", err
+		}
+		// partials are indented like the line holding the marker
+		line := content[strings.LastIndex(content[:loc[0]], "\n")+1 : loc[0]]
+		indent := strings.Repeat(" ", countIndent(line, len(line)-len(strings.TrimLeft(line, " \t"))))
+		out.WriteString(content[last:loc[0]])
+		out.WriteString(indentLines(partial, indent))
+		last = loc[1]
+	}
+	out.WriteString(content[last:])
+	return out.String(), nil
+}
+
+// loadPartial reads and expands a partial, the stack holds the partials
+// currently being included to detect cycles
+func loadPartial(name string, stack []string) (string, error) {
+	if util.StringInArray(stack, name) {
+		return "", errors.New("Include cycle detected: " + strings.Join(append(stack, name), " -> "))
+	}
+	if len(stack) >= MaxIncludeDepth {
+		return "", errors.New("Include depth limit of " + strconv.Itoa(MaxIncludeDepth) + " exceeded: " + strings.Join(append(stack, name), " -> "))
+	}
+
+	path, err := getPartialPath(name)
+	if nil != err {
+		return "", err
+	}
+	partial, ok := partials[path]
+	if !ok {
+		data, err := os.ReadFile(path)
+		if nil != err {
+			return "", errors.New("Could not read partial '" + name + "' with error '" + err.Error() + "'")
+		}
+		// the trailing newline of the file would break inline usage
+		partial = strings.TrimSuffix(string(data), "\n")
+		if ".md" == filepath.Ext(name) {
+			tmp := converter.Content{
+				Md:      partial,
+				Options: converterOptions,
+			}
+			tmp.Convert()
+			// the partial is part of a document which already is wrapped
+			partial = strings.TrimSpace(tmp.Html)
+			partial = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(partial, "<div>"), "</div>"))
+		}
+		partials[path] = partial
+	}
+	return expandIncludes(partial, append(stack, name), ".md" == filepath.Ext(name))
+}
+
+// getPartialPath resolves the name of a partial in the partials directory.
+// Names which are project relative paths into the partials directory, like
+// partials/header.html, are taken as such.
+func getPartialPath(name string) (string, error) {
+	path := filepath.Join(projectPath, name)
+	if !isInPartialsPath(path) {
+		path = filepath.Join(partialsPath, name)
+	}
+	if !isInPartialsPath(path) {
+		return "", errors.New("Partial '" + name + "' is outside of the partials directory '" + partialsPath + "'")
+	}
+	return path, nil
+}
+
+func isInPartialsPath(path string) bool {
+	rel, err := filepath.Rel(partialsPath, path)
+	return nil == err && "." != rel && !strings.HasPrefix(rel, "..")
+}
+
+// UnwrapIncludes removes the paragraph the markdown converter wraps around
+// an include marker standing on its own line
+func UnwrapIncludes(html string) string {
+	tmp := regexp.MustCompile(includeParagraphRxp)
+	return tmp.ReplaceAllString(html, "$1")
+}
+
+// indentLines prefixes all but the first line with indent, the first line
+// takes the place of the marker which already is indented
+func indentLines(content string, indent string) string {
+	if "" == indent {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	for i := 1; i < len(lines); i++ {
+		if "" != strings.TrimSpace(lines[i]) {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,102 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+func setTestPartials(t *testing.T, files map[string]string) {
+	project := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(project, "partials", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); nil != err {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); nil != err {
+			t.Fatal(err)
+		}
+	}
+	SetPartialsPath(project, "partials")
+}
+
+func TestExpandIncludes(t *testing.T) {
+	setTestPartials(t, map[string]string{
+		"header.html":     "<header>\n  {{include:nav/menu.html}}\n</header>\n",
+		"nav/menu.html":   "<ul>\n  <li>a</li>\n</ul>",
+		"text.md":         "some **bold**",
+		"cycle-a.html":    "a {{include:cycle-b.html}}",
+		"cycle-b.html":    "b {{include:cycle-a.html}}",
+		"recursive.html":  "{{include:recursive.html}}",
+		"../outside.html": "secret",
+	})
+	tests := []struct {
+		name     string
+		content  string
+		markdown bool
+		want     string
+		wantErr  string
+	}{
+		{"nested and indented", "  {{include:header.html}}", false, "  <header>\n    <ul>\n      <li>a</li>\n    </ul>\n  </header>", ""},
+		{"project relative", "{{include:partials/nav/menu.html}}", false, "<ul>\n  <li>a</li>\n</ul>", ""},
+		{"markdown", "<p>{{include:text.md}}</p>", false, "<p><p>\nsome <b>bold</b>\n  </p></p>", ""},
+		{"inline", "x {{include:nav/menu.html}} y", false, "x <ul>\n  <li>a</li>\n</ul> y", ""},
+		{"cycle", "{{include:cycle-a.html}}", false, "", "Include cycle detected: cycle-a.html -> cycle-b.html -> cycle-a.html"},
+		{"self", "{{include:recursive.html}}", false, "", "Include cycle detected"},
+		{"outside", "{{include:../outside.html}}", false, "", "outside of the partials directory"},
+		{"missing", "{{include:missing.html}}", false, "", "Could not read partial 'missing.html'"},
+		{"escaped", "\\{{include:text.md}} {{{{include:text.md}}}}", false, "\\{{include:text.md}} {{{{include:text.md}}}}", ""},
+		{"code of markdown", "<code>{{include:text.md}}</code>", true, "<code>{{include:text.md}}</code>", ""},
+		{"code of html", "<code>{{include:nav/menu.html}}</code>", false, "<code><ul>\n  <li>a</li>\n</ul></code>", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ExpandIncludes(test.content, test.markdown)
+			if "" != test.wantErr {
+				if nil == err || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("expected error %q but got %v", test.wantErr, err)
+				}
+				return
+			}
+			if nil != err {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestExpandIncludesDepthLimit(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i <= MaxIncludeDepth; i++ {
+		files[strconv.Itoa(i)+".html"] = "{{include:" + strconv.Itoa(i+1) + ".html}}"
+	}
+	files[strconv.Itoa(MaxIncludeDepth+1)+".html"] = "end"
+
+	// a chain of exactly MaxIncludeDepth partials is fine
+	setTestPartials(t, files)
+	got, err := ExpandIncludes("{{include:2.html}}", false)
+	if nil != err || "end" != got {
+		t.Errorf("expected %q but got %q with error %v", "end", got, err)
+	}
+
+	_, err = ExpandIncludes("{{include:1.html}}", false)
+	if nil == err || !strings.Contains(err.Error(), "Include depth limit of "+strconv.Itoa(MaxIncludeDepth)+" exceeded") {
+		t.Errorf("expected the depth limit error but got %v", err)
+	}
+}
+
+func TestRenderPageContentIncludes(t *testing.T) {
+	setTestPartials(t, map[string]string{"box.html": "<div class='box'>\n  box\n</div>"})
+	page := types.Page{Name: "Page", Type: "md", Content: "# Title\n\n{{include:box.html}}\n\ntext"}
+	html := renderPageContent(page, false)
+	if !strings.Contains(html, "\n  <div class='box'>\n    box\n  </div>\n") || strings.Contains(html, "<p>\n<div") {
+		t.Errorf("expected the unwrapped partial in %q", html)
+	}
+}
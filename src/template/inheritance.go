@@ -0,0 +1,144 @@
+package template
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+const extendsRxp = `\{\{extends:([^}]+)\}\}`
+const blockTagRxp = `\{\{block:([a-zA-Z0-9_-]+)\}\}|\{\{endblock\}\}`
+
+// templateBlock is a {{block:name}}...{{endblock}} section, Start and End
+// span the whole section including its tags
+type templateBlock struct {
+	Name  string
+	Body  string
+	Start int
+	End   int
+}
+
+// ResolveInheritance resolves {{extends:parent.html}} of a template. The
+// blocks of the child replace the blocks of the same name in the parent,
+// blocks which are not overridden keep the body of the parent. Parents may
+// extend further templates, parent paths are relative to the given
+// directory.
+func ResolveInheritance(file string, directory string) (string, error) {
+	return resolveInheritance(file, directory, make(map[string]string), []string{})
+}
+
+func resolveInheritance(file string, directory string, overrides map[string]string, stack []string) (string, error) {
+	if util.StringInArray(stack, file) {
+		return "", errors.New("Template inheritance cycle detected: " + strings.Join(append(stack, file), " -> "))
+	}
+	stack = append(stack, file)
+	data, err := os.ReadFile(file)
+	if nil != err {
+		return "", errors.New("Could not read template '" + file + "' with error '" + err.Error() + "'")
+	}
+	content := string(data)
+
+	// blocks of the child win over the ones of its parents
+	blocks, err := collectBlocks(content)
+	if nil != err {
+		return "", errors.New(err.Error() + " in template '" + file + "'")
+	}
+	for _, block := range blocks {
+		if _, ok := overrides[block.Name]; !ok {
+			overrides[block.Name] = block.Body
+		}
+	}
+
+	match := regexp.MustCompile(extendsRxp).FindStringSubmatch(content)
+	if nil != match {
+		parent := filepath.Join(directory, strings.TrimSpace(match[1]))
+		if _, err := os.Stat(parent); nil != err {
+			// reported at the extends marker of the child
+			line := strings.Count(content[:strings.Index(content, match[0])], "\n") + 1
+			return "", errors.New("Parent template '" + strings.TrimSpace(match[1]) + "' of '" + file + "' line " + strconv.Itoa(line) + " does not exist")
+		}
+		return resolveInheritance(parent, directory, overrides, stack)
+	}
+	// the root template with the blocks filled in
+	return renderBlocks(content, overrides)
+}
+
+// collectBlocks returns all blocks of a template including nested ones
+func collectBlocks(content string) ([]templateBlock, error) {
+	blocks, err := parseBlocks(content)
+	if nil != err {
+		return nil, err
+	}
+	var all []templateBlock
+	names := make(map[string]bool)
+	for _, block := range blocks {
+		nested, err := collectBlocks(block.Body)
+		if nil != err {
+			return nil, err
+		}
+		for _, found := range append([]templateBlock{block}, nested...) {
+			if names[found.Name] {
+				return nil, errors.New("Block '" + found.Name + "' is defined twice")
+			}
+			names[found.Name] = true
+			all = append(all, found)
+		}
+	}
+	return all, nil
+}
+
+// parseBlocks returns the top level blocks of a template
+func parseBlocks(content string) ([]templateBlock, error) {
+	var blocks []templateBlock
+	var open []templateBlock
+	tmp := regexp.MustCompile(blockTagRxp)
+	for _, loc := range tmp.FindAllStringSubmatchIndex(content, -1) {
+		if -1 != loc[2] {
+			open = append(open, templateBlock{Name: content[loc[2]:loc[3]], Start: loc[0], End: loc[1]})
+			continue
+		}
+		if 0 == len(open) {
+			return nil, errors.New("{{endblock}} without {{block:...}}")
+		}
+		block := open[len(open)-1]
+		open = open[:len(open)-1]
+		if 0 < len(open) {
+			// nested blocks are handled with the body of their parent
+			continue
+		}
+		block.Body = content[block.End:loc[0]]
+		block.End = loc[1]
+		blocks = append(blocks, block)
+	}
+	if 0 < len(open) {
+		return nil, errors.New("Block '" + open[0].Name + "' is not closed")
+	}
+	return blocks, nil
+}
+
+// renderBlocks replaces every block with its override, blocks inside of
+// the chosen bodies are replaced as well
+func renderBlocks(content string, overrides map[string]string) (string, error) {
+	blocks, err := parseBlocks(content)
+	if nil != err {
+		return "", err
+	}
+	var out strings.Builder
+	last := 0
+	for _, block := range blocks {
+		out.WriteString(content[last:block.Start])
+		body, err := renderBlocks(overrides[block.Name], overrides)
+		if nil != err {
+			return "", err
+		}
+		out.WriteString(body)
+		last = block.End
+	}
+	out.WriteString(content[last:])
+	return out.String(), nil
+}
@@ -0,0 +1,58 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveInheritance(t *testing.T) {
+	directory := t.TempDir()
+	files := map[string]string{
+		"base.html":    "<title>{{block:title}}Site{{endblock}}</title>\n<main>{{block:main}}{{block:intro}}hi{{endblock}}{{endblock}}</main>",
+		"section.html": "{{extends:base.html}}\n{{block:intro}}section{{endblock}}",
+		"page.html":    "{{extends:section.html}}\n{{block:title}}Page{{endblock}}",
+		"main.html":    "{{extends:base.html}}\n{{block:main}}replaced{{endblock}}",
+		"missing.html": "a\n{{extends:nope.html}}",
+		"cycle-a.html": "{{extends:cycle-b.html}}",
+		"cycle-b.html": "{{extends:cycle-a.html}}",
+		"twice.html":   "{{extends:base.html}}\n{{block:a}}{{endblock}}{{block:a}}{{endblock}}",
+		"open.html":    "{{block:a}}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0644); nil != err {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		file    string
+		want    string
+		wantErr string
+	}{
+		{"base.html", "<title>Site</title>\n<main>hi</main>", ""},
+		{"page.html", "<title>Page</title>\n<main>section</main>", ""},
+		{"main.html", "<title>Site</title>\n<main>replaced</main>", ""},
+		{"missing.html", "", "Parent template 'nope.html' of '" + filepath.Join(directory, "missing.html") + "' line 2 does not exist"},
+		{"cycle-a.html", "", "Template inheritance cycle detected"},
+		{"twice.html", "", "Block 'a' is defined twice"},
+		{"open.html", "", "Block 'a' is not closed"},
+	}
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			got, err := ResolveInheritance(filepath.Join(directory, test.file), directory)
+			if "" != test.wantErr {
+				if nil == err || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("expected error %q but got %v", test.wantErr, err)
+				}
+				return
+			}
+			if nil != err {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
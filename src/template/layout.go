@@ -0,0 +1,125 @@
+package template
+
+import (
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+// LayoutFile is the name of the layout file of a pages directory, it
+// applies to all pages of the directory and its subdirectories
+const LayoutFile = "_layout.html"
+
+// Layout is a parsed layout template, GoTemplate is set if it is rendered
+// by the go template engine and Nodes otherwise
+type Layout struct {
+	File       string
+	Nodes      []TemplateNode
+	GoTemplate *htmltemplate.Template
+}
+
+var layoutProjectDirectory string
+var layoutPagesDirectory string
+var defaultLayoutFile string
+var layouts = make(map[string]Layout)
+
+// SetLayouts sets the directories layouts are resolved in and the layout
+// used if a page has no other, usually the mainFile
+func SetLayouts(projectDirectory string, pagesDirectory string, defaultLayout string) {
+	layoutProjectDirectory = projectDirectory
+	layoutPagesDirectory = pagesDirectory
+	defaultLayoutFile = defaultLayout
+	layouts = make(map[string]Layout)
+}
+
+// GetPageLayout returns the parsed layout template of a page.
+// A layout set in the front matter (relative to the project directory)
+// wins over the nearest _layout.html of the page directory or its parents,
+// which wins over the default layout.
+func GetPageLayout(page types.Page) Layout {
+	file := resolveLayoutFile(page)
+	return GetLayout(file)
+}
+
+// GetLayout returns a layout template with its parent templates resolved,
+// its partials included and parsed, every layout is only parsed once
+func GetLayout(file string) Layout {
+	if cached, ok := layouts[file]; ok {
+		return cached
+	}
+	if EngineGo == templateEngine {
+		layouts[file] = Layout{File: file, GoTemplate: parseGoLayout(file)}
+		return layouts[file]
+	}
+	// errors are reported and the layout is used as far as possible
+	source, err := os.ReadFile(file)
+	if nil != err {
+		util.ReportError(util.SourceError{File: file, Message: "Reading layout failed with error '" + err.Error() + "'"})
+	}
+	content, err := ResolveInheritance(file, layoutProjectDirectory)
+	if nil != err {
+		util.ReportError(util.SourceError{File: file, Message: "Resolving layout failed with error '" + err.Error() + "'"})
+		content = string(source)
+	}
+	expanded, err := ExpandIncludes(content, false)
+	if nil != err {
+		util.ReportError(util.SourceError{File: file, Message: "Including partials into layout failed with error '" + err.Error() + "'"})
+	} else {
+		content = expanded
+	}
+	nodes, err := ParseTemplateFile(content, file)
+	if nil != err {
+		util.ReportError(relocateSourceError(err, file, false))
+		nodes = []TemplateNode{{Kind: NodeText, Text: content}}
+	}
+	if content != string(source) {
+		RelocateTemplateMarkers(nodes, string(source), file)
+	}
+	layouts[file] = Layout{File: file, Nodes: nodes}
+	return layouts[file]
+}
+
+// RenderLayout renders a layout with the rendered page as content
+func RenderLayout(layout Layout, context RenderContext) string {
+	if nil != layout.GoTemplate {
+		return renderGoLayout(layout, context)
+	}
+	return RenderTemplate(layout.Nodes, context)
+}
+
+func resolveLayoutFile(page types.Page) string {
+	if name, ok := page.Meta["layout"]; ok && "" != name {
+		file := filepath.Join(layoutProjectDirectory, name)
+		if _, err := os.Stat(file); nil == err {
+			return file
+		}
+		// the page is reported and built with the default layout
+		util.ReportError(util.SourceError{File: filepath.Join(page.Path, page.Filename), Message: "Layout '" + name + "' does not exist"})
+		return defaultLayoutFile
+	}
+
+	// walk up from the page directory to the pages directory
+	if "" != layoutPagesDirectory && isInDirectory(page.Path, layoutPagesDirectory) {
+		directory := page.Path
+		for {
+			file := filepath.Join(directory, LayoutFile)
+			if _, err := os.Stat(file); nil == err {
+				return file
+			}
+			if filepath.Clean(directory) == filepath.Clean(layoutPagesDirectory) {
+				break
+			}
+			directory = filepath.Dir(directory)
+		}
+	}
+	return defaultLayoutFile
+}
+
+func isInDirectory(path string, directory string) bool {
+	rel, err := filepath.Rel(directory, path)
+	return nil == err && !strings.HasPrefix(rel, "..")
+}
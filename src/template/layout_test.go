@@ -0,0 +1,50 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+func TestGetPageLayout(t *testing.T) {
+	project := t.TempDir()
+	files := map[string]string{
+		"main.html":               "main {{render:content}}",
+		"layouts/special.html":    "special {{render:content}}",
+		"pages/blog/_layout.html": "blog {{render:content}}",
+	}
+	for name, content := range files {
+		path := filepath.Join(project, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); nil != err {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); nil != err {
+			t.Fatal(err)
+		}
+	}
+	pages := filepath.Join(project, "pages")
+	SetLayouts(project, pages, filepath.Join(project, "main.html"))
+	defer SetLayouts("", "", "")
+
+	tests := []struct {
+		name string
+		page types.Page
+		want string
+	}{
+		{"default", types.Page{Path: pages}, "main content"},
+		{"directory layout", types.Page{Path: filepath.Join(pages, "blog")}, "blog content"},
+		{"parent directory layout", types.Page{Path: filepath.Join(pages, "blog", "2024")}, "blog content"},
+		{"front matter wins", types.Page{Path: filepath.Join(pages, "blog"), Meta: map[string]string{"layout": "layouts/special.html"}}, "special content"},
+		{"index page", types.Page{Path: project}, "main content"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := RenderLayout(GetPageLayout(test.page), RenderContext{Content: "content"})
+			if test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
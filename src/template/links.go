@@ -0,0 +1,86 @@
+package template
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+const BrokenLinksWarn = "warn"
+const BrokenLinksError = "error"
+const BrokenLinksIgnore = "ignore"
+
+// pageIndex maps the absolute source path of every page to its built url
+var pageIndex = make(map[string]string)
+var brokenLinks = BrokenLinksWarn
+
+func SetBrokenLinksMode(mode string) {
+	brokenLinks = mode
+}
+
+func BuildPageIndex(pageGroups map[string]types.Pagegroup) {
+	for _, group := range pageGroups {
+		for _, page := range group.Entries {
+			if "link" == page.Type {
+				RegisterPageUrl(filepath.Join(page.Path, page.Filename), strings.TrimSpace(page.Content))
+			} else {
+				RegisterPageUrl(filepath.Join(page.Path, page.Filename), buildInternalUrl(group.Ident, page))
+			}
+		}
+	}
+}
+
+func RegisterPageUrl(sourcePath string, url string) {
+	pageIndex[filepath.Clean(sourcePath)] = url
+}
+
+// ResolvePageLink rewrites relative links to page source files, like
+// [setup](2.Setup.md) or [api](../docs/1.API.md), to the url of the built
+// page. Links to .md files which are no known page are reported as broken,
+// all other links are returned unchanged.
+func ResolvePageLink(page types.Page, href string) string {
+	return resolvePageLink(page, href, true)
+}
+
+func resolvePageLink(page types.Page, href string, report bool) string {
+	parsed, err := url.Parse(href)
+	if nil != err || "" != parsed.Scheme || "" != parsed.Host || "" == parsed.Path || strings.HasPrefix(parsed.Path, "/") {
+		return href
+	}
+	ext := strings.TrimPrefix(filepath.Ext(parsed.Path), ".")
+	if !util.StringInArray(GetAllowedTemplateExt(), ext) {
+		return href
+	}
+
+	sourcePath := filepath.Clean(filepath.Join(page.Path, filepath.FromSlash(parsed.Path)))
+	pageUrl, ok := pageIndex[sourcePath]
+	if !ok {
+		// .html might as well be a link to an already built page
+		if "md" == ext && report {
+			reportBrokenLink(page, href)
+		}
+		return href
+	}
+	if "" != parsed.RawQuery {
+		pageUrl = pageUrl + "?" + parsed.RawQuery
+	}
+	if "" != parsed.Fragment {
+		pageUrl = pageUrl + "#" + parsed.Fragment
+	}
+	return pageUrl
+}
+
+func reportBrokenLink(page types.Page, href string) {
+	switch brokenLinks {
+	case BrokenLinksIgnore:
+		return
+	case BrokenLinksError:
+		// reported at the line of the link in the page file
+		util.ReportError(newPageSourceError(filepath.Join(page.Path, page.Filename), href, "Link to non existing page '"+href+"'"))
+	default:
+		util.Print("> Warning: Page '" + page.Filename + "' links to non existing page '" + href + "'")
+	}
+}
@@ -0,0 +1,45 @@
+package template
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+func TestResolvePageLink(t *testing.T) {
+	defer SetBrokenLinksMode(BrokenLinksWarn)
+	SetBrokenLinksMode(BrokenLinksIgnore)
+	pageIndex = make(map[string]string)
+	BuildPageIndex(map[string]types.Pagegroup{
+		"/": {Ident: "/", Entries: []types.Page{
+			{Path: filepath.FromSlash("/site/pages"), Filename: "1.Home.md", UrlName: "Home", Type: "md"},
+			{Path: filepath.FromSlash("/site/pages"), Filename: "2.Repo.link", Type: "link", Content: "https://github.com/x\n"},
+		}},
+		"docs": {Ident: "docs", Entries: []types.Page{
+			{Path: filepath.FromSlash("/site/pages/docs"), Filename: "1.API.md", UrlName: "API", Type: "md"},
+		}},
+	})
+	page := types.Page{Path: filepath.FromSlash("/site/pages/docs"), Filename: "1.API.md"}
+	tests := []struct {
+		href string
+		want string
+	}{
+		{"../1.Home.md", "Home.html"},
+		{"1.API.md#usage", "docs/API.html#usage"},
+		{"1.API.md?a=b", "docs/API.html?a=b"},
+		{"../2.Repo.link", "https://github.com/x"},
+		{"missing.md", "missing.md"},
+		{"https://example.com/1.Home.md", "https://example.com/1.Home.md"},
+		{"/1.Home.md", "/1.Home.md"},
+		{"image.png", "image.png"},
+		{"#top", "#top"},
+	}
+	for _, test := range tests {
+		t.Run(test.href, func(t *testing.T) {
+			if got := ResolvePageLink(page, test.href); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package template
+
+import (
+	"errors"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+// NavTree is the value of a nav marker rendering all pagegroups as tree
+const NavTree = "tree"
+
+// navOptions are the options given to a nav marker like
+// {{nav:/blog:date:desc:limit=5:exclude=Imprint,Privacy}}
+type navOptions struct {
+	SortBy     string
+	Descending bool
+	Limit      int
+	Exclude    []string
+	Excerpt    bool
+	Depth      int
+	Collapse   bool
+}
+
+// parseNavOptions parses the options of a nav marker, invalid options are
+// skipped and returned as error
+func parseNavOptions(options []string) (navOptions, error) {
+	var invalid []string
+	parsed := navOptions{SortBy: "sequence"}
+	for _, option := range options {
+		key, value := option, ""
+		if idx := strings.Index(option, "="); -1 != idx {
+			key, value = option[:idx], option[idx+1:]
+		}
+		switch key {
+		case "asc":
+			parsed.Descending = false
+		case "desc":
+			parsed.Descending = true
+		case "alpha", "date":
+			parsed.SortBy = key
+		case "limit":
+			limit, err := strconv.Atoi(value)
+			if nil != err || 0 > limit {
+				invalid = append(invalid, "'"+option+"' (limit must be a positive number)")
+				continue
+			}
+			parsed.Limit = limit
+		case "exclude":
+			for _, entry := range strings.Split(value, ",") {
+				if entry = strings.TrimSpace(entry); "" != entry {
+					parsed.Exclude = append(parsed.Exclude, entry)
+				}
+			}
+		case "excerpt":
+			parsed.Excerpt = true
+		case "depth":
+			depth, err := strconv.Atoi(value)
+			if nil != err || 1 > depth {
+				invalid = append(invalid, "'"+option+"' (depth must be at least 1)")
+				continue
+			}
+			parsed.Depth = depth
+		case "collapse":
+			parsed.Collapse = true
+		default:
+			invalid = append(invalid, "'"+option+"' (unknown option)")
+		}
+	}
+	if 0 < len(invalid) {
+		return parsed, errors.New("Invalid nav options " + strings.Join(invalid, ", "))
+	}
+	return parsed, nil
+}
+
+// getNavEntries returns the entries of a pagegroup filtered, sorted and
+// limited as requested by the nav options. Excluded entries can be given
+// by name, url name or filename.
+func getNavEntries(entries []types.Page, options navOptions) []types.Page {
+	var filtered []types.Page
+	for _, page := range entries {
+		if util.StringInArray(options.Exclude, page.Name) || util.StringInArray(options.Exclude, page.UrlName) || util.StringInArray(options.Exclude, page.Filename) {
+			continue
+		}
+		filtered = append(filtered, page)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := filtered[i], filtered[j]
+		if options.Descending {
+			a, b = b, a
+		}
+		switch options.SortBy {
+		case "alpha":
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		case "date":
+			return a.Date.Before(b.Date)
+		}
+		return a.Sequence < b.Sequence
+	})
+
+	if 0 < options.Limit && len(filtered) > options.Limit {
+		filtered = filtered[:options.Limit]
+	}
+	return filtered
+}
+
+// BuildNavTree renders the pages directory as nested lists, every
+// subdirectory becomes a parent item. If the parent directory contains a
+// page named like the subdirectory, that page is used as the parent item,
+// otherwise the directory name is shown. Items on the path to the current
+// page get the class 'active-parent', the current page 'active'. With
+// depth=N only N levels are rendered, with collapse only the branch of the
+// current page is expanded.
+func BuildNavTree(pageGroups map[string]types.Pagegroup, indents int, currPage types.Page, currIdent string, options []string) string {
+	navOptions, _ := parseNavOptions(options)
+	return buildNavTreeLevel(pageGroups, "/", 1, strings.Repeat(" ", indents), currPage, currIdent, navOptions)
+}
+
+func buildNavTreeLevel(pageGroups map[string]types.Pagegroup, ident string, level int, spacing string, currPage types.Page, currIdent string, options navOptions) string {
+	subIdents := getSubGroupIdents(pageGroups, ident)
+	usedIdents := make(map[string]bool)
+	var items []string
+
+	for _, page := range getNavEntries(pageGroups[ident].Entries, options) {
+		class := ""
+		children := ""
+		if isCurrentPage(page, ident, currPage, currIdent) {
+			class = "active"
+		}
+		// a page named like a subdirectory is its parent item
+		for _, subIdent := range subIdents {
+			if "link" != page.Type && !usedIdents[subIdent] && strings.EqualFold(page.UrlName, path.Base(subIdent)) {
+				usedIdents[subIdent] = true
+				children = buildNavTreeChildren(pageGroups, subIdent, level, spacing, currPage, currIdent, options)
+				if "" == class && isInNavBranch(subIdent, currIdent) {
+					class = "active-parent"
+				}
+				break
+			}
+		}
+		items = append(items, buildNavTreeItem(class, buildNavLink(page, ident, options), children, spacing))
+	}
+
+	// directories without a page of their name
+	for _, subIdent := range subIdents {
+		if usedIdents[subIdent] || util.StringInArray(options.Exclude, path.Base(subIdent)) {
+			continue
+		}
+		class := ""
+		if isInNavBranch(subIdent, currIdent) {
+			class = "active-parent"
+		}
+		children := buildNavTreeChildren(pageGroups, subIdent, level, spacing, currPage, currIdent, options)
+		items = append(items, buildNavTreeItem(class, "<span>"+path.Base(subIdent)+"</span>", children, spacing))
+	}
+
+	if 0 == len(items) {
+		return ""
+	}
+	return "<ul>" + strings.Join(items, "") + "\n" + spacing + "</ul>"
+}
+
+func buildNavTreeChildren(pageGroups map[string]types.Pagegroup, ident string, level int, spacing string, currPage types.Page, currIdent string, options navOptions) string {
+	if 0 < options.Depth && level >= options.Depth {
+		return ""
+	}
+	if options.Collapse && !isInNavBranch(ident, currIdent) {
+		return ""
+	}
+	return buildNavTreeLevel(pageGroups, ident, level+1, spacing+"    ", currPage, currIdent, options)
+}
+
+func buildNavTreeItem(class string, link string, children string, spacing string) string {
+	if "" != class {
+		class = " class='" + class + "'"
+	}
+	if "" != children {
+		children = "\n" + spacing + "    " + children + "\n" + spacing + "  "
+	}
+	return "\n" + spacing + "  <li" + class + ">" + link + children + "</li>"
+}
+
+// getSubGroupIdents returns the idents of the direct subdirectories of a
+// pagegroup, including directories which only contain further directories
+func getSubGroupIdents(pageGroups map[string]types.Pagegroup, ident string) []string {
+	prefix := ident + "/"
+	if "/" == ident {
+		prefix = "/"
+	}
+	found := make(map[string]bool)
+	var subIdents []string
+	for groupIdent := range pageGroups {
+		if groupIdent == ident || !strings.HasPrefix(groupIdent, prefix) {
+			continue
+		}
+		subIdent := prefix + strings.SplitN(strings.TrimPrefix(groupIdent, prefix), "/", 2)[0]
+		if !found[subIdent] {
+			found[subIdent] = true
+			subIdents = append(subIdents, subIdent)
+		}
+	}
+	sort.Strings(subIdents)
+	return subIdents
+}
+
+func isInNavBranch(ident string, currIdent string) bool {
+	return currIdent == ident || strings.HasPrefix(currIdent, ident+"/")
+}
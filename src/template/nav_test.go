@@ -0,0 +1,141 @@
+package template
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+func TestGetNavEntries(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+	entries := []types.Page{
+		{Name: "beta", UrlName: "beta", Filename: "1.beta.md", Sequence: 1, Date: day(3)},
+		{Name: "Alpha", UrlName: "Alpha", Filename: "2.Alpha.md", Sequence: 2, Date: day(1)},
+		{Name: "Imprint", UrlName: "Imprint", Filename: "3.Imprint.md", Sequence: 3, Date: day(2)},
+	}
+	tests := []struct {
+		name    string
+		options []string
+		want    []string
+	}{
+		{"sequence by default", nil, []string{"beta", "Alpha", "Imprint"}},
+		{"desc", []string{"desc"}, []string{"Imprint", "Alpha", "beta"}},
+		{"alpha ignores case", []string{"alpha"}, []string{"Alpha", "beta", "Imprint"}},
+		{"date desc", []string{"date", "desc"}, []string{"beta", "Imprint", "Alpha"}},
+		{"limit", []string{"limit=2"}, []string{"beta", "Alpha"}},
+		{"exclude by name and filename", []string{"exclude=Imprint,1.beta.md"}, []string{"Alpha"}},
+		{"exclude then limit", []string{"exclude=beta", "limit=1"}, []string{"Alpha"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options, err := parseNavOptions(test.options)
+			if nil != err {
+				t.Fatalf("unexpected error %s", err)
+			}
+			got := getNavEntries(entries, options)
+			var names []string
+			for _, page := range got {
+				names = append(names, page.Name)
+			}
+			if len(test.want) != len(names) {
+				t.Fatalf("expected %q but got %q", test.want, names)
+			}
+			for i := range names {
+				if test.want[i] != names[i] {
+					t.Errorf("expected %q but got %q", test.want, names)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestParseNavOptions(t *testing.T) {
+	options, err := parseNavOptions([]string{"date", "desc", "limit=5", "exclude= a , b", "excerpt"})
+	if nil != err {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if "date" != options.SortBy || !options.Descending || 5 != options.Limit || !options.Excerpt {
+		t.Errorf("unexpected options %+v", options)
+	}
+	if 2 != len(options.Exclude) || "a" != options.Exclude[0] || "b" != options.Exclude[1] {
+		t.Errorf("expected excludes [a b] but got %q", options.Exclude)
+	}
+	_, err = parseNavOptions([]string{"limit=x", "depth=0", "nope"})
+	want := "Invalid nav options 'limit=x' (limit must be a positive number), 'depth=0' (depth must be at least 1), 'nope' (unknown option)"
+	if nil == err || want != err.Error() {
+		t.Errorf("expected error %q but got %v", want, err)
+	}
+}
+
+func TestBuildNavTree(t *testing.T) {
+	pageGroups := map[string]types.Pagegroup{
+		"/": {Ident: "/", Entries: []types.Page{
+			{Name: "Home", UrlName: "Home", Filename: "1.Home.md", Type: "md", Sequence: 1},
+			{Name: "Docs", UrlName: "Docs", Filename: "2.Docs.md", Type: "md", Sequence: 2},
+		}},
+		"/docs": {Ident: "/docs", Entries: []types.Page{
+			{Name: "Setup", UrlName: "Setup", Filename: "1.Setup.md", Type: "md", Sequence: 1},
+		}},
+		"/docs/api": {Ident: "/docs/api", Entries: []types.Page{
+			{Name: "Client", UrlName: "Client", Filename: "1.Client.md", Type: "md", Sequence: 1},
+		}},
+		"/blog": {Ident: "/blog", Entries: []types.Page{
+			{Name: "Post", UrlName: "Post", Filename: "1.Post.md", Type: "md", Sequence: 1},
+		}},
+	}
+	current := pageGroups["/docs/api"].Entries[0]
+	tests := []struct {
+		name    string
+		options []string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "full tree",
+			want: []string{
+				"<li class='active-parent'><a href='Docs.html'>Docs</a>",
+				"<li class='active-parent'><span>api</span>",
+				"<li class='active'><a href='docs/api/Client.html'>Client</a></li>",
+				"<li><span>blog</span>",
+				"<a href='blog/Post.html'>Post</a>",
+			},
+		},
+		{
+			name:    "depth",
+			options: []string{"depth=2"},
+			want:    []string{"<a href='docs/Setup.html'>Setup</a>", "<span>api</span>"},
+			notWant: []string{"Client.html"},
+		},
+		{
+			name:    "collapse",
+			options: []string{"collapse"},
+			want:    []string{"<span>blog</span></li>", "Client.html"},
+			notWant: []string{"Post.html"},
+		},
+		{
+			name:    "exclude directory",
+			options: []string{"exclude=blog"},
+			notWant: []string{"blog"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nav := BuildNavTree(pageGroups, 0, current, "/docs/api", test.options)
+			for _, want := range test.want {
+				if !strings.Contains(nav, want) {
+					t.Errorf("expected %q in %q", want, nav)
+				}
+			}
+			for _, notWant := range test.notWant {
+				if strings.Contains(nav, notWant) {
+					t.Errorf("expected no %q in %q", notWant, nav)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package template
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+var pagerAcrossDirectories = false
+
+// SetPagerAcrossDirectories makes prev/next links continue into sub- and
+// parent directories in depth-first order instead of stopping at the
+// boundaries of the current directory
+func SetPagerAcrossDirectories(across bool) {
+	pagerAcrossDirectories = across
+}
+
+// pagerEntry is a page with the ident of the pagegroup it belongs to
+type pagerEntry struct {
+	Page  types.Page
+	Ident string
+}
+
+// BuildPagerLink renders the link to the previous or next page as used by
+// {{render:prev}} and {{render:next}}, empty if there is none
+func BuildPagerLink(pageGroups map[string]types.Pagegroup, currPage types.Page, currIdent string, direction string) string {
+	entry, ok := getPagerEntry(pageGroups, currPage, currIdent, direction)
+	if !ok {
+		return ""
+	}
+	url := buildInternalUrl(entry.Ident, entry.Page)
+	if "prev" == direction {
+		return "<a class='prev' rel='prev' href='" + url + "'>← " + entry.Page.Name + "</a>"
+	}
+	return "<a class='next' rel='next' href='" + url + "'>" + entry.Page.Name + " →</a>"
+}
+
+// GetPagerValue returns the url or title of the previous or next page as
+// used by {{var:prev.url}} or {{var:next.title}}, empty if there is none
+func GetPagerValue(pageGroups map[string]types.Pagegroup, currPage types.Page, currIdent string, key string) (string, bool) {
+	parts := strings.SplitN(key, ".", 2)
+	if 2 != len(parts) || ("prev" != parts[0] && "next" != parts[0]) || ("url" != parts[1] && "title" != parts[1]) {
+		return "", false
+	}
+	entry, ok := getPagerEntry(pageGroups, currPage, currIdent, parts[0])
+	if !ok {
+		return "", true
+	}
+	if "url" == parts[1] {
+		return buildInternalUrl(entry.Ident, entry.Page), true
+	}
+	return entry.Page.Name, true
+}
+
+func getPagerEntry(pageGroups map[string]types.Pagegroup, currPage types.Page, currIdent string, direction string) (pagerEntry, bool) {
+	// index and 404 page are not part of any group
+	if "" == currIdent {
+		return pagerEntry{}, false
+	}
+	var entries []pagerEntry
+	if pagerAcrossDirectories {
+		entries = getPagerEntriesRecursive(pageGroups, "/")
+	} else {
+		entries = getPagerEntries(pageGroups, currIdent)
+	}
+	for i, entry := range entries {
+		if entry.Ident != currIdent || entry.Page.Filename != currPage.Filename {
+			continue
+		}
+		if "prev" == direction && 0 < i {
+			return entries[i-1], true
+		}
+		if "next" == direction && i+1 < len(entries) {
+			return entries[i+1], true
+		}
+		break
+	}
+	return pagerEntry{}, false
+}
+
+// getPagerEntries returns the pages of a group by sequence, link type
+// entries are skipped
+func getPagerEntries(pageGroups map[string]types.Pagegroup, ident string) []pagerEntry {
+	var entries []pagerEntry
+	for _, page := range pageGroups[ident].Entries {
+		if "link" != page.Type {
+			entries = append(entries, pagerEntry{Page: page, Ident: ident})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Page.Sequence < entries[j].Page.Sequence
+	})
+	return entries
+}
+
+// getPagerEntriesRecursive returns all pages in depth-first order. Like in
+// the nav tree a subdirectory follows the page of its name, directories
+// without such a page follow the pages of their parent.
+func getPagerEntriesRecursive(pageGroups map[string]types.Pagegroup, ident string) []pagerEntry {
+	var entries []pagerEntry
+	subIdents := getSubGroupIdents(pageGroups, ident)
+	usedIdents := make(map[string]bool)
+	for _, entry := range getPagerEntries(pageGroups, ident) {
+		entries = append(entries, entry)
+		for _, subIdent := range subIdents {
+			if !usedIdents[subIdent] && strings.EqualFold(entry.Page.UrlName, path.Base(subIdent)) {
+				usedIdents[subIdent] = true
+				entries = append(entries, getPagerEntriesRecursive(pageGroups, subIdent)...)
+				break
+			}
+		}
+	}
+	for _, subIdent := range subIdents {
+		if !usedIdents[subIdent] {
+			entries = append(entries, getPagerEntriesRecursive(pageGroups, subIdent)...)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,55 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+func TestPager(t *testing.T) {
+	defer SetPagerAcrossDirectories(false)
+	pageGroups := map[string]types.Pagegroup{
+		"/": {Ident: "/", Entries: []types.Page{
+			{Name: "Home", UrlName: "Home", Filename: "1.Home.md", Type: "md", Sequence: 1},
+			{Name: "Docs", UrlName: "Docs", Filename: "2.Docs.md", Type: "md", Sequence: 2},
+			{Name: "GitHub", Filename: "3.GitHub.link", Type: "link", Sequence: 3},
+			{Name: "About", UrlName: "About", Filename: "4.About.md", Type: "md", Sequence: 4},
+		}},
+		"/docs": {Ident: "/docs", Entries: []types.Page{
+			{Name: "Setup", UrlName: "Setup", Filename: "1.Setup.md", Type: "md", Sequence: 1},
+		}},
+	}
+	tests := []struct {
+		name   string
+		across bool
+		page   types.Page
+		ident  string
+		key    string
+		want   string
+	}{
+		{"next skips links", false, pageGroups["/"].Entries[1], "/", "next.title", "About"},
+		{"no prev on first page", false, pageGroups["/"].Entries[0], "/", "prev.url", ""},
+		{"stops at directory", false, pageGroups["/docs"].Entries[0], "/docs", "next.url", ""},
+		{"across into directory", true, pageGroups["/"].Entries[1], "/", "next.url", "docs/Setup.html"},
+		{"across out of directory", true, pageGroups["/docs"].Entries[0], "/docs", "next.title", "About"},
+		{"index page", false, types.Page{Name: "Site", UrlName: "index"}, "", "next.url", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SetPagerAcrossDirectories(test.across)
+			got, ok := GetPagerValue(pageGroups, test.page, test.ident, test.key)
+			if !ok || test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+
+	if _, ok := GetPagerValue(pageGroups, pageGroups["/"].Entries[0], "/", "next.date"); ok {
+		t.Errorf("expected next.date not to be a pager value")
+	}
+	SetPagerAcrossDirectories(false)
+	want := "<a class='prev' rel='prev' href='Home.html'>← Home</a>"
+	if got := BuildPagerLink(pageGroups, pageGroups["/"].Entries[1], "/", "prev"); want != got {
+		t.Errorf("expected %q but got %q", want, got)
+	}
+}
@@ -0,0 +1,46 @@
+package template
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+// code is skimmed rather than read, it doesn't count as words
+const statsCodeRxp = `(?s)<pre[\s>].*?</pre>|<code[\s>].*?</code>|<script[\s>].*?</script>|<style[\s>].*?</style>`
+const statsMarkerRxp = `\{\{[^{}]*\}\}`
+
+var wordsPerMinute = 200
+
+func SetWordsPerMinute(words int) {
+	wordsPerMinute = words
+}
+
+// SetPageStats counts the words and characters (without whitespace) of the
+// html the page was converted to, code and markers are excluded
+func SetPageStats(page *types.Page) {
+	content := getPageHtml(*page, false)
+	content = regexp.MustCompile(statsCodeRxp).ReplaceAllString(content, " ")
+	content = regexp.MustCompile(statsMarkerRxp).ReplaceAllString(content, " ")
+	content = html.UnescapeString(regexp.MustCompile(excerptTagsRxp).ReplaceAllString(content, ""))
+	page.WordCount = len(strings.Fields(content))
+	page.CharCount = utf8.RuneCountInString(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, content))
+}
+
+// GetReadingTime returns the estimated minutes to read a page, rounded up
+// so every page with content takes at least a minute
+func GetReadingTime(page types.Page) int {
+	if 0 >= page.WordCount || 0 >= wordsPerMinute {
+		return 0
+	}
+	return (page.WordCount + wordsPerMinute - 1) / wordsPerMinute
+}
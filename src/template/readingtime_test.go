@@ -0,0 +1,68 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+func TestSetPageStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		page      types.Page
+		wordCount int
+		charCount int
+	}{
+		{"markdown", types.Page{Type: "md", Content: "# Hello world\n\nSome *bold* text &amp; more."}, 7, 28},
+		{"code and markers are excluded", types.Page{Type: "md", Content: "one `two` three {{var:x}}\n\n```\nfour five\n```"}, 2, 8},
+		{"cached html is used", types.Page{Type: "md", Content: "one two three", Html: "<p>four <b>five</b></p><!--more-->"}, 2, 8},
+		{"html page", types.Page{Type: "html", Content: "<p>a&nbsp;b</p><script>var x = 1;</script>"}, 2, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := test.page
+			SetPageStats(&page)
+			if test.wordCount != page.WordCount || test.charCount != page.CharCount {
+				t.Errorf("expected %d words and %d chars but got %d and %d", test.wordCount, test.charCount, page.WordCount, page.CharCount)
+			}
+		})
+	}
+}
+
+func TestGetReadingTime(t *testing.T) {
+	defer SetWordsPerMinute(200)
+	SetWordsPerMinute(100)
+	tests := []struct {
+		words int
+		want  int
+	}{
+		{0, 0},
+		{1, 1},
+		{100, 1},
+		{101, 2},
+	}
+	for _, test := range tests {
+		if got := GetReadingTime(types.Page{WordCount: test.words}); test.want != got {
+			t.Errorf("expected %d minutes for %d words but got %d", test.want, test.words, got)
+		}
+	}
+}
+
+func TestRenderPageStats(t *testing.T) {
+	pageGroups := map[string]types.Pagegroup{
+		"/blog": {Ident: "/blog", Entries: []types.Page{
+			{Name: "One", UrlName: "One", Filename: "1.One.md", Type: "md", Sequence: 1, Content: "one two three"},
+		}},
+	}
+	PreparePages(pageGroups, nil)
+	page := pageGroups["/blog"].Entries[0]
+	nodes, err := ParseTemplate("{{page:wordcount}} {{page:charcount}} {{page:readingtime}}|{{each:group:/blog}}{{item:wordcount}} {{item:readingtime}}{{end}}")
+	if nil != err {
+		t.Fatalf("unexpected error %s", err)
+	}
+	got := RenderTemplate(nodes, RenderContext{Page: page, PageGroups: pageGroups})
+	if want := "3 11 1|3 1"; !strings.Contains(got, want) {
+		t.Errorf("expected %q but got %q", want, got)
+	}
+}
@@ -0,0 +1,291 @@
+package template
+
+import (
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/voodooEntity/gomcmf/src/converter"
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+const ShortcodeStagePre = "pre"
+const ShortcodeStagePost = "post"
+
+const shortcodeOpenDelimiter = "{{<"
+const shortcodeCloseDelimiter = ">}}"
+const shortcodeOpenRxp = `\{\{<\s*(/?)([a-zA-Z0-9_-]+)((?:[^>]|>[^}])*?)\s*>\}\}`
+const shortcodeParamRxp = `([a-zA-Z0-9_-]+)=(?:"([^"]*)"|'([^']*)'|(\S+))`
+const shortcodeStageRxp = `^\s*<!--\s*shortcode:\s*(pre|post)\s*-->\s*\n?`
+const shortcodeMarkerRxp = `\{\{(param:[^}:]+(?::[^}]*)?|inner:content)\}\}`
+const shortcodePlaceholderPrefix = "GOMCMFSHORTCODE"
+const shortcodeParagraphRxp = `<p>\s*(` + shortcodePlaceholderPrefix + `\d+X)\s*</p>`
+
+// fenced code blocks and inline code of markdown, shortcodes in them are
+// documentation and kept as written
+const markdownCodeRegionRxp = "(?s)(?m:^```).*?(?m:^```)|`[^`\n]+`"
+
+// ShortcodeError is an invalid shortcode, Tag is the shortcode as written
+// to find its position in the page
+type ShortcodeError struct {
+	Tag     string
+	Message string
+}
+
+func (self ShortcodeError) Error() string {
+	return self.Message
+}
+
+// Shortcode is a reusable html snippet from the shortcodes directory.
+// Stage decides whether it is expanded before the markdown conversion
+// (pre, the snippet output is converted as markdown) or after it (post,
+// default). A snippet declares the pre stage with a leading
+// <!-- shortcode: pre --> comment.
+type Shortcode struct {
+	Name    string
+	Stage   string
+	Snippet string
+}
+
+var shortcodes = make(map[string]Shortcode)
+
+func LoadShortcodes(directory string) {
+	if _, err := os.Stat(directory); os.IsNotExist(err) {
+		return
+	}
+	files, err := os.ReadDir(directory)
+	if nil != err {
+		util.Error("Could not read shortcodes directory '" + directory + "' with error '" + err.Error() + "'")
+	}
+	stageRxp := regexp.MustCompile(shortcodeStageRxp)
+	for _, file := range files {
+		if file.IsDir() || ".html" != filepath.Ext(file.Name()) {
+			continue
+		}
+		// the trailing newline of the file would break inline usage
+		snippet := strings.TrimSuffix(util.ReadFile(filepath.Join(directory, file.Name())), "\n")
+		shortcode := Shortcode{
+			Name:    strings.TrimSuffix(file.Name(), ".html"),
+			Stage:   ShortcodeStagePost,
+			Snippet: snippet,
+		}
+		if match := stageRxp.FindStringSubmatch(snippet); nil != match {
+			shortcode.Stage = match[1]
+			shortcode.Snippet = snippet[len(match[0]):]
+		}
+		shortcodes[shortcode.Name] = shortcode
+	}
+}
+
+// ExpandShortcodes replaces all shortcodes of the given stage with their
+// rendered snippet, shortcodes of other stages are left untouched. In
+// markdown the shortcodes of code blocks and inline code are kept as well.
+func ExpandShortcodes(content string, stage string, markdown bool) (string, error) {
+	return replaceShortcodes(content, markdown, func(shortcode Shortcode, params map[string]string, inner string) (string, bool) {
+		return renderShortcode(shortcode, params, inner), stage == shortcode.Stage
+	})
+}
+
+// ProtectShortcodes replaces all post stage shortcodes of markdown with
+// placeholders that survive the markdown conversion. Since the converter
+// never sees the inner content of these shortcodes it is converted here.
+// The rendered snippets are returned in the order they were protected, to
+// be put back by RestoreShortcodes.
+func ProtectShortcodes(content string) (string, []string, error) {
+	var protected []string
+	content, err := replaceShortcodes(content, true, func(shortcode Shortcode, params map[string]string, inner string) (string, bool) {
+		if ShortcodeStagePost != shortcode.Stage {
+			return "", false
+		}
+		protected = append(protected, renderShortcode(shortcode, params, convertShortcodeInner(inner)))
+		return getShortcodePlaceholder(len(protected) - 1), true
+	})
+	return content, protected, err
+}
+
+// RestoreShortcodes puts the protected snippets back. Nested shortcodes are
+// protected before the one enclosing them, so the snippets are restored in
+// reverse order. A shortcode standing on its own line is taken out of the
+// paragraph the converter wraps around it.
+func RestoreShortcodes(content string, protected []string) string {
+	content = regexp.MustCompile(shortcodeParagraphRxp).ReplaceAllString(content, "$1")
+	for i := len(protected) - 1; i >= 0; i-- {
+		content = strings.ReplaceAll(content, getShortcodePlaceholder(i), protected[i])
+	}
+	return content
+}
+
+func getShortcodePlaceholder(index int) string {
+	return shortcodePlaceholderPrefix + strconv.Itoa(index) + "X"
+}
+
+// convertShortcodeInner converts the markdown inner content of a post stage
+// shortcode, a single line only for its inline elements so it can be used
+// within a line
+func convertShortcodeInner(inner string) string {
+	inner = strings.TrimSpace(inner)
+	if "" == inner {
+		return ""
+	}
+	tmp := converter.Content{
+		Md:      inner,
+		Options: converterOptions,
+	}
+	if strings.Contains(inner, "\n") {
+		tmp.Convert()
+	} else {
+		tmp.ConvertInline()
+	}
+	return tmp.Html
+}
+
+// replaceShortcodes replaces the shortcodes with the result of replace.
+// Unknown shortcodes and stray closing tags are kept as written, the first
+// of them is returned as error after all others were replaced.
+func replaceShortcodes(content string, markdown bool, replace func(shortcode Shortcode, params map[string]string, inner string) (string, bool)) (string, error) {
+	var codeRegions [][]int
+	if markdown {
+		codeRegions = getMarkdownCodeRegions(content)
+	}
+	var out strings.Builder
+	var firstErr error
+	pos := 0
+	for {
+		loc := findShortcode(content, pos, codeRegions)
+		if nil == loc {
+			out.WriteString(content[pos:])
+			return out.String(), firstErr
+		}
+		closing := content[loc[2]:loc[3]]
+		name := content[loc[4]:loc[5]]
+		shortcode, ok := shortcodes[name]
+		if "/" == closing || !ok {
+			if nil == firstErr {
+				message := "unknown shortcode '" + name + "'"
+				if "/" == closing {
+					message = "closing shortcode '" + name + "' without opening shortcode"
+				}
+				firstErr = ShortcodeError{Tag: content[loc[0]:loc[1]], Message: message}
+			}
+			out.WriteString(content[pos:loc[1]])
+			pos = loc[1]
+			continue
+		}
+		params := parseShortcodeParams(content[loc[6]:loc[7]])
+
+		// paired shortcodes enclose an inner content up to their closing tag
+		end := loc[1]
+		inner := ""
+		if closeLoc := findClosingShortcode(content, name, loc[1], codeRegions); nil != closeLoc {
+			inner = content[loc[1]:closeLoc[0]]
+			end = closeLoc[1]
+			expandedInner, err := replaceShortcodes(inner, markdown, replace)
+			if nil != err && nil == firstErr {
+				firstErr = err
+			}
+			inner = expandedInner
+		}
+
+		out.WriteString(content[pos:loc[0]])
+		if replacement, ok := replace(shortcode, params, inner); ok {
+			out.WriteString(replacement)
+		} else {
+			// keep the shortcode for a later stage
+			out.WriteString(content[loc[0]:end])
+		}
+		pos = end
+	}
+}
+
+// findShortcode returns the submatch indices of the next opening or closing
+// shortcode tag from pos on which isn't in a code region
+func findShortcode(content string, pos int, codeRegions [][]int) []int {
+	openRxp := regexp.MustCompile(shortcodeOpenRxp)
+	for {
+		loc := openRxp.FindStringSubmatchIndex(content[pos:])
+		if nil == loc {
+			return nil
+		}
+		for i := range loc {
+			if -1 != loc[i] {
+				loc[i] += pos
+			}
+		}
+		if regionEnd := getRegionEnd(codeRegions, loc[0]); -1 != regionEnd {
+			pos = regionEnd
+			continue
+		}
+		return loc
+	}
+}
+
+// findClosingShortcode returns the position of the closing tag of the named
+// shortcode, nil if another shortcode of the same name opens before it, so
+// an unpaired shortcode doesn't swallow the text up to a later pair
+func findClosingShortcode(content string, name string, pos int, codeRegions [][]int) []int {
+	for {
+		loc := findShortcode(content, pos, codeRegions)
+		if nil == loc {
+			return nil
+		}
+		if name == content[loc[4]:loc[5]] {
+			if "/" == content[loc[2]:loc[3]] {
+				return []int{loc[0], loc[1]}
+			}
+			return nil
+		}
+		pos = loc[1]
+	}
+}
+
+// getMarkdownCodeRegions returns the start and end of all fenced code
+// blocks and inline code of markdown
+func getMarkdownCodeRegions(str string) [][]int {
+	if !strings.Contains(str, "`") {
+		return nil
+	}
+	return regexp.MustCompile(markdownCodeRegionRxp).FindAllStringIndex(str, -1)
+}
+
+// getRegionEnd returns the end of the region containing index or -1
+func getRegionEnd(regions [][]int, index int) int {
+	for _, region := range regions {
+		if index >= region[0] && index < region[1] {
+			return region[1]
+		}
+	}
+	return -1
+}
+
+func parseShortcodeParams(args string) map[string]string {
+	params := make(map[string]string)
+	paramRxp := regexp.MustCompile(shortcodeParamRxp)
+	for _, match := range paramRxp.FindAllStringSubmatch(args, -1) {
+		params[match[1]] = match[2] + match[3] + match[4]
+	}
+	return params
+}
+
+// renderShortcode substitutes {{param:key}}, {{param:key:default}} and
+// {{inner:content}} in the shortcode snippet. Parameter values are html
+// escaped, the defaults are part of the snippet and used as written.
+func renderShortcode(shortcode Shortcode, params map[string]string, inner string) string {
+	markerRxp := regexp.MustCompile(shortcodeMarkerRxp)
+	return markerRxp.ReplaceAllStringFunc(shortcode.Snippet, func(match string) string {
+		marker := strings.Trim(match, "{}")
+		if "inner:content" == marker {
+			return inner
+		}
+		parts := strings.SplitN(strings.TrimPrefix(marker, "param:"), ":", 2)
+		if value, ok := params[parts[0]]; ok {
+			return html.EscapeString(value)
+		}
+		if 2 == len(parts) {
+			return parts[1]
+		}
+		return ""
+	})
+}
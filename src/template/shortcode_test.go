@@ -0,0 +1,93 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/converter"
+)
+
+func setTestShortcodes() {
+	shortcodes = map[string]Shortcode{
+		"note":  {Name: "note", Stage: ShortcodeStagePost, Snippet: "<div class='note-{{param:kind:info}}'>{{param:title}}{{inner:content}}</div>"},
+		"badge": {Name: "badge", Stage: ShortcodeStagePost, Snippet: "<span>{{inner:content}}</span>"},
+		"warn":  {Name: "warn", Stage: ShortcodeStagePre, Snippet: "**{{inner:content}}**"},
+	}
+}
+
+func TestExpandShortcodes(t *testing.T) {
+	setTestShortcodes()
+	tests := []struct {
+		name     string
+		content  string
+		stage    string
+		markdown bool
+		want     string
+		wantErr  string
+	}{
+		{"inline", `a {{< note title="T" >}} b`, ShortcodeStagePost, false, "a <div class='note-info'>T</div> b", ""},
+		{"default overridden", `{{< note kind=warn >}}`, ShortcodeStagePost, false, "<div class='note-warn'></div>", ""},
+		{"parameters are escaped", `{{< note title="<b>&" >}}`, ShortcodeStagePost, false, "<div class='note-info'>&lt;b&gt;&amp;</div>", ""},
+		{"paired", `{{< badge >}}x{{< /badge >}}`, ShortcodeStagePost, false, "<span>x</span>", ""},
+		{"nested", `{{< note >}}{{< badge >}}x{{< /badge >}}{{< /note >}}`, ShortcodeStagePost, false, "<div class='note-info'><span>x</span></div>", ""},
+		{"unpaired before pair", `{{< badge >}} a {{< badge >}}x{{< /badge >}}`, ShortcodeStagePost, false, "<span></span> a <span>x</span>", ""},
+		{"other stage kept", `{{< warn >}}x{{< /warn >}}`, ShortcodeStagePost, false, `{{< warn >}}x{{< /warn >}}`, ""},
+		{"pre stage", `{{< warn >}}x{{< /warn >}}`, ShortcodeStagePre, false, "**x**", ""},
+		{"inline code kept", "see `{{< badge >}}`", ShortcodeStagePost, true, "see `{{< badge >}}`", ""},
+		{"code block kept", "```\n{{< badge >}}\n```", ShortcodeStagePost, true, "```\n{{< badge >}}\n```", ""},
+		{"unknown is kept", `{{< badge >}}x{{< /badge >}} {{< nope a="1" >}}`, ShortcodeStagePost, false, `<span>x</span> {{< nope a="1" >}}`, "unknown shortcode 'nope'"},
+		{"first error is returned", `{{< /badge >}} {{< nope >}}`, ShortcodeStagePost, false, `{{< /badge >}} {{< nope >}}`, "closing shortcode 'badge' without opening shortcode"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ExpandShortcodes(test.content, test.stage, test.markdown)
+			if "" != test.wantErr {
+				if nil == err || test.wantErr != err.Error() {
+					t.Errorf("expected error %q but got %v", test.wantErr, err)
+				}
+			} else if nil != err {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestProtectShortcodes(t *testing.T) {
+	setTestShortcodes()
+	tests := []struct {
+		name string
+		md   string
+		want []string
+	}{
+		{"inline inner content is converted", "a {{< badge >}}**b**{{< /badge >}} c", []string{"a <span><b>b</b></span> c"}},
+		{"block inner content is converted", "{{< badge >}}\n- x\n- y\n{{< /badge >}}", []string{"<span><div>", "<li>x</li>"}},
+		{"own line is no paragraph", "a\n\n{{< note >}}\n\nb", []string{"</p>\n\n  <div class='note-info'></div>\n\n  <p>"}},
+		{"nested", "{{< note >}}{{< badge >}}x{{< /badge >}}{{< /note >}}", []string{"<div class='note-info'><span>x</span></div>"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content, protected, err := ProtectShortcodes(test.md)
+			if nil != err {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if strings.Contains(content, "{{<") {
+				t.Errorf("expected no shortcode in %q", content)
+			}
+			html := renderTestMarkdown(content, protected)
+			for _, want := range test.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("expected %q in %q", want, html)
+				}
+			}
+		})
+	}
+}
+
+func renderTestMarkdown(md string, protected []string) string {
+	tmp := converter.Content{Md: md}
+	tmp.Convert()
+	return RestoreShortcodes(tmp.Html, protected)
+}
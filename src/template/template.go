@@ -6,12 +6,20 @@ import (
 	"github.com/voodooEntity/gomcmf/src/types"
 	"github.com/voodooEntity/gomcmf/src/util"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
+var converterOptions converter.Options
+
+func SetConverterOptions(options converter.Options) {
+	converterOptions = options
+}
+
 func GetNextSequence(directory string) int {
 	sequences := getAllSequences(directory)
 	if 0 == len(sequences) {
@@ -69,7 +77,7 @@ func GetAllTemplateFiles(directory string) []types.Page {
 		util.Error(err.Error())
 	}
 	for _, file := range files {
-		if file.IsDir() || !hasAllowedExt(file.Name(), GetAllowedTemplateExt()) {
+		if file.IsDir() || !hasAllowedExt(file.Name(), GetAllowedTemplateExt()) || LayoutFile == file.Name() {
 			continue
 		}
 		page, err := GetPageByPathAndFilename(directory, file.Name())
@@ -89,6 +97,7 @@ func GetPageByPathAndFilename(path string, filename string) (types.Page, error)
 	}
 	urlSafeName := GetUrlSafeName(filename)
 	fullPath := path + "/" + filename
+	meta, content := ParseFrontMatter(util.ReadFile(fullPath))
 	page := types.Page{
 		Filename: filename,
 		UrlName:  urlSafeName,
@@ -96,51 +105,176 @@ func GetPageByPathAndFilename(path string, filename string) (types.Page, error)
 		Name:     name,
 		Type:     ext,
 		Sequence: GetSequenceFromFilename(filename),
-		Content:  util.ReadFile(fullPath),
+		Content:  content,
+		Meta:     meta,
+		Date:     GetPageDate(fullPath, meta),
 	}
 	return page, nil
 }
 
+// GetPageDate returns the date set in the front matter, else the time of
+// the last git commit of the page file or its modification time
+func GetPageDate(fullPath string, meta map[string]string) time.Time {
+	if value, ok := meta["date"]; ok {
+		date, ok := parseFrontMatterDate(value)
+		if ok {
+			return date
+		}
+		util.Print("> Warning: Invalid date '" + value + "' in front matter of '" + fullPath + "', using the last commit or file modification time")
+	}
+	if date, ok := getGitDate(fullPath); ok {
+		return date
+	}
+	info, err := os.Stat(fullPath)
+	if nil != err {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
 func RenderPage(
 	page types.Page,
-	mainTemplate string,
-	mainTemplateReplacements []types.Replacement,
+	layout Layout,
 	variables map[string]string,
 	pageGroups map[string]types.Pagegroup,
 	groupIdent string,
 ) string {
-	// prestore content
+	pageContent := removeExcerptSeparator(getPageHtml(page, true))
+
+	// the content was converted and expanded, positions of markers are
+	// taken from the page file
+	sourceFile := filepath.Join(page.Path, page.Filename)
+	pageTemplate, err := parseTemplate(pageContent, sourceFile, getPageCodeRegions(page, pageContent))
+	if nil != err {
+		util.ReportError(relocateSourceError(err, sourceFile, "md" == page.Type))
+		pageTemplate = []TemplateNode{{Kind: NodeText, Text: pageContent}}
+	}
+	if source, err := os.ReadFile(sourceFile); nil == err {
+		RelocateTemplateMarkers(pageTemplate, string(source), sourceFile)
+	}
+	context := RenderContext{
+		Variables:  variables,
+		PageGroups: pageGroups,
+		Page:       page,
+		GroupIdent: groupIdent,
+	}
+	pageContent = RenderTemplate(pageTemplate, context)
+	if "md" == page.Type {
+		pageContent = joinAdjacentLists(pageContent)
+	}
+
+	// now render the layout with the page as content
+	context.Content = pageContent
+	finalPage := RenderLayout(layout, context)
+
+	return finalPage
+}
+
+// getPageHtml returns the html the page was converted to by PreparePage or
+// else converts it now
+func getPageHtml(page types.Page, reportBrokenLinks bool) string {
+	if "" != page.Html {
+		return page.Html
+	}
+	return renderPageContent(page, reportBrokenLinks)
+}
+
+// renderPageContent expands the shortcodes of the page, converts markdown
+// pages to html and includes the partials, the markers are left for the
+// caller
+func renderPageContent(page types.Page, reportBrokenLinks bool) string {
+	// errors are reported with the page file and the build goes on
+	sourceFile := filepath.Join(page.Path, page.Filename)
+	reportPageError := func(message string, err error) {
+		target := ""
+		if shortcodeErr, ok := err.(ShortcodeError); ok {
+			target = shortcodeErr.Tag
+		}
+		util.ReportError(newPageSourceError(sourceFile, target, message+" - error: '"+err.Error()+"'"))
+	}
+
 	pageContent := page.Content
+	if "md" == page.Type {
+		pageContent = JoinMultilineMarkers(pageContent)
+	}
+
+	// expand shortcodes which declare to be processed before the conversion,
+	// invalid shortcodes are kept as written and reported once
+	pageContent, err := ExpandShortcodes(pageContent, ShortcodeStagePre, "md" == page.Type)
+	if nil != err {
+		reportPageError("Error expanding shortcodes", err)
+	}
 
-	// if its md we render it
 	if "md" == page.Type {
+		// post stage shortcodes are kept away from the converter
+		protectedContent, protectedShortcodes, _ := ProtectShortcodes(pageContent)
+		options := converterOptions
+		options.File = sourceFile
+		options.LineOffset = getContentLineOffset(sourceFile, page.Content)
+		options.ResolveLink = func(href string) string {
+			return resolvePageLink(page, href, reportBrokenLinks)
+		}
 		tmp := converter.Content{
-			Md: pageContent,
+			Md:      protectedContent,
+			Options: options,
 		}
 		tmp.Convert()
-		// overwrite content
-		pageContent = tmp.Html
+		pageContent = RestoreShortcodes(UnwrapIncludes(tmp.Html), protectedShortcodes)
+	} else {
+		// html pages only get their post stage shortcodes
+		pageContent, _ = ExpandShortcodes(pageContent, ShortcodeStagePost, false)
 	}
 
-	pageReplacements, err := GetReplacementMarkers(pageContent)
+	expandedContent, err := ExpandIncludes(pageContent, "md" == page.Type)
 	if nil != err {
-		util.Error("Error parsing page '" + page.Name + "' for markers - error: '" + err.Error() + "'")
+		reportPageError("Error including partials", err)
+	} else {
+		pageContent = expandedContent
 	}
+	return pageContent
+}
 
-	// replace all markers in template
-	for _, pageReplacement := range pageReplacements {
-		value := GetReplacementContent(pageReplacement, variables, pageGroups, "", page, groupIdent)
-		pageContent = strings.ReplaceAll(pageContent, "{{"+pageReplacement.Target+"}}", value)
-	}
+// RenderPageFormat renders the page as gemtext or plain text from the same
+// html the page is rendered to. Only var markers and if and each constructs
+// are rendered, all other markers are dropped since they produce html. The
+// page groups are needed by each loops over groups.
+func RenderPageFormat(
+	page types.Page,
+	format string,
+	textWidth int,
+	variables map[string]string,
+	pageGroups map[string]types.Pagegroup,
+	groupIdent string,
+) string {
+	// broken links are already reported when rendering the html
+	pageContent := renderVarMarkers(page, getPageHtml(page, false), variables, pageGroups, groupIdent)
 
-	// now replace markers in main template
-	finalPage := mainTemplate
-	for _, mainTemplateReplacement := range mainTemplateReplacements {
-		value := GetReplacementContent(mainTemplateReplacement, variables, pageGroups, pageContent, page, groupIdent)
-		finalPage = strings.ReplaceAll(finalPage, "{{"+mainTemplateReplacement.Target+"}}", value)
+	tmp := converter.Content{
+		Html:    pageContent,
+		Options: converterOptions,
+	}
+	if converter.FormatGemtext == format {
+		return tmp.ToGemtext()
 	}
+	return tmp.ToText(textWidth)
+}
 
-	return finalPage
+// renderVarMarkers renders the if and each constructs and var markers of
+// the content and drops all other markers, for outputs which can't take
+// the html they produce
+func renderVarMarkers(page types.Page, content string, variables map[string]string, pageGroups map[string]types.Pagegroup, groupIdent string) string {
+	nodes, err := parseTemplate(content, filepath.Join(page.Path, page.Filename), getPageCodeRegions(page, content))
+	if nil != err {
+		// already reported when rendering the html
+		nodes = []TemplateNode{{Kind: NodeText, Text: content}}
+	}
+	return RenderTemplate(nodes, RenderContext{
+		Variables:  variables,
+		PageGroups: pageGroups,
+		Page:       page,
+		GroupIdent: groupIdent,
+		VarsOnly:   true,
+	})
 }
 
 func GetReplacementContent(
@@ -153,28 +287,154 @@ func GetReplacementContent(
 ) string {
 	switch replacement.Type {
 	case "nav":
+		// the whole pages directory as nested lists
+		if NavTree == replacement.Value {
+			if _, err := parseNavOptions(replacement.Options); nil != err {
+				reportMarkerError(replacement, err.Error())
+			}
+			return BuildNavTree(pageGroups, replacement.Indents, currPage, groupIdent, replacement.Options)
+		}
 		// If the requested pagegroup exists
 		val, ok := pageGroups[replacement.Value]
 		if !ok {
-			util.Error("Tryied to render non existing pagegroup '" + replacement.Value + "'")
+			reportMarkerError(replacement, "Tryied to render non existing pagegroup '"+replacement.Value+"'")
+			return ""
+		}
+		if _, err := parseNavOptions(replacement.Options); nil != err {
+			reportMarkerError(replacement, err.Error())
+		}
+		return BuildPageGroupNav(val, replacement.Indents, currPage, groupIdent, replacement.Options)
+	case "page":
+		if "date" == replacement.Value {
+			// layouts may contain colons like {{page:date:15:04}}
+			return FormatDate(currPage.Date, strings.Join(replacement.Options, ":"))
+		}
+		val, ok := GetPageValue(currPage, groupIdent, replacement.Value)
+		if !ok {
+			reportMarkerError(replacement, "Tryied to render non existing page field '"+replacement.Value+"'")
 		}
-		return BuildPageGroupNav(val, replacement.Indents, currPage, groupIdent)
+		return val
 	case "var":
+		// prev.url, next.title and so on depend on the current page
+		if val, ok := GetPagerValue(pageGroups, currPage, groupIdent, replacement.Value); ok {
+			return val
+		}
+		if BuildDateVariable == replacement.Value && 0 < len(replacement.Options) {
+			return FormatDate(buildDate, strings.Join(replacement.Options, ":"))
+		}
 		val, ok := variables[replacement.Value]
 		if !ok {
-			util.Error("Tryied to render non existing variable '" + replacement.Value + "'")
+			reportMarkerError(replacement, "Tryied to render non existing variable '"+replacement.Value+"'")
 		}
 		return val
+	case "data":
+		val, ok := GetDataValue(replacement.Value)
+		if !ok {
+			reportMarkerError(replacement, "Tryied to render non existing data '"+replacement.Value+"'")
+		}
+		return formatDataValue(val)
 	case "render":
 		if "content" == replacement.Value {
 			return content
 		}
+		if "prev" == replacement.Value || "next" == replacement.Value {
+			return BuildPagerLink(pageGroups, currPage, groupIdent, replacement.Value)
+		}
+		if "breadcrumbs" == replacement.Value {
+			return BuildBreadcrumbs(pageGroups, variables, replacement.Indents, currPage, groupIdent, replacement.Options)
+		}
 	}
-	util.Error("Unknown replacment type '" + replacement.Type + "' given")
+	reportMarkerError(replacement, "Unknown replacment type '"+replacement.Type+"' given")
 	return ""
 }
 
-func BuildPageGroupNav(pagegroup types.Pagegroup, indents int, currPage types.Page, currIdent string) string {
+// GetPageValue returns a field or front matter value of the current page
+// as used by {{page:field}}, false if the page has no such field
+func GetPageValue(page types.Page, groupIdent string, field string) (string, bool) {
+	switch field {
+	case "name":
+		return page.Name, true
+	case "url":
+		if "" == groupIdent {
+			return page.UrlName + ".html", true
+		}
+		return buildInternalUrl(groupIdent, page), true
+	case "wordcount":
+		return strconv.Itoa(page.WordCount), true
+	case "charcount":
+		return strconv.Itoa(page.CharCount), true
+	case "readingtime":
+		return strconv.Itoa(GetReadingTime(page)), true
+	case "excerpt":
+		return page.Excerpt, true
+	}
+	// all other fields come from the front matter
+	val, ok := page.Meta[field]
+	return val, ok
+}
+
+// getContentLineOffset returns the number of lines in front of the page
+// content in the page file, like the lines of its front matter
+func getContentLineOffset(file string, content string) int {
+	data, err := os.ReadFile(file)
+	if nil != err {
+		return 0
+	}
+	offset := strings.Count(string(data), "\n") - strings.Count(content, "\n")
+	if 0 > offset {
+		return 0
+	}
+	return offset
+}
+
+// newPageSourceError returns an error at the first occurrence of target in
+// the page file, without a position if it isn't found
+func newPageSourceError(file string, target string, message string) util.SourceError {
+	err := util.SourceError{File: file, Message: message}
+	if "" == target {
+		return err
+	}
+	if source, readErr := os.ReadFile(file); nil == readErr {
+		if index := strings.Index(string(source), target); -1 != index {
+			err.Line, err.Column = getSourcePosition(string(source), index)
+		}
+	}
+	return err
+}
+
+// getPageCodeRegions returns the code blocks of the html of markdown pages,
+// markers inside of them are documentation
+func getPageCodeRegions(page types.Page, html string) [][]int {
+	if "md" != page.Type {
+		return nil
+	}
+	return getCodeRegions(html)
+}
+
+// relocateSourceError moves the position of a parse error of converted or
+// expanded content to the source file by parsing the file itself, without
+// a position if the file parses fine
+func relocateSourceError(err error, file string, markdown bool) util.SourceError {
+	if source, readErr := os.ReadFile(file); nil == readErr {
+		var codeRegions [][]int
+		if markdown {
+			codeRegions = getMarkdownCodeRegions(string(source))
+		}
+		if _, sourceErr := parseTemplate(string(source), file, codeRegions); nil != sourceErr {
+			if located, ok := sourceErr.(util.SourceError); ok {
+				return located
+			}
+		}
+	}
+	message := err.Error()
+	if located, ok := err.(util.SourceError); ok {
+		message = located.Message
+	}
+	return util.SourceError{File: file, Message: message}
+}
+
+func BuildPageGroupNav(pagegroup types.Pagegroup, indents int, currPage types.Page, currIdent string, options []string) string {
+	navOptions, _ := parseNavOptions(options)
 	nav := ""
 	if 0 < len(pagegroup.Entries) {
 		spacing := ""
@@ -182,22 +442,47 @@ func BuildPageGroupNav(pagegroup types.Pagegroup, indents int, currPage types.Pa
 			spacing = strings.Repeat(" ", indents)
 		}
 		nav = nav + "<ul>"
-		for _, page := range pagegroup.Entries {
-			if "link" == page.Type {
-				nav = nav + "\n" + spacing + "  <li><a href='" + page.Content + "' target='_blank'>" + page.Name + "</a></li>"
-			} else {
-				active := ""
-				if currPage.Filename == page.Filename && pagegroup.Ident == currIdent {
-					active = " class='active'"
-				}
-				nav = nav + "\n" + spacing + "  <li" + active + "><a href='" + buildInternalUrl(pagegroup.Ident, page) + "'>" + page.Name + "</a></li>"
+		for _, page := range getNavEntries(pagegroup.Entries, navOptions) {
+			active := ""
+			if isCurrentPage(page, pagegroup.Ident, currPage, currIdent) {
+				active = " class='active'"
 			}
+			nav = nav + "\n" + spacing + "  <li" + active + ">" + buildNavLink(page, pagegroup.Ident, navOptions) + "</li>"
 		}
 		nav = nav + "\n" + spacing + "</ul>"
 	}
 	return nav
 }
 
+// buildNavLink returns the anchor of a nav entry followed by its excerpt
+// if requested
+func buildNavLink(page types.Page, ident string, options navOptions) string {
+	if "link" == page.Type {
+		href := strings.TrimSpace(page.Content)
+		return "<a href='" + href + "'" + getNavLinkAttributes(href) + ">" + page.Name + "</a>"
+	}
+	url := buildInternalUrl(ident, page)
+	excerpt := ""
+	if options.Excerpt && "" != page.Excerpt {
+		excerpt = "<p class='excerpt'>" + page.Excerpt + "</p>"
+	}
+	return "<a href='" + url + "'" + converter.GetLinkAttributes(converterOptions.LinkPolicy, converterOptions.Base, url).String() + ">" + page.Name + "</a>" + excerpt
+}
+
+func isCurrentPage(page types.Page, ident string, currPage types.Page, currIdent string) bool {
+	return "link" != page.Type && currPage.Filename == page.Filename && ident == currIdent
+}
+
+// getNavLinkAttributes returns the attributes for link type nav entries,
+// without a configured link policy the default nav link policy applies
+func getNavLinkAttributes(href string) string {
+	policy := converterOptions.LinkPolicy
+	if nil == policy {
+		policy = converter.DefaultNavLinkPolicy
+	}
+	return converter.GetLinkAttributes(policy, converterOptions.Base, href).String()
+}
+
 func buildInternalUrl(ident string, page types.Page) string {
 	urlPath := ""
 	if "/" != ident {
@@ -254,42 +539,14 @@ func GetAllowedTemplateExt() []string {
 	return allowedExts
 }
 
+// GetReplacementMarkers returns all markers of a template, including the
+// ones inside of if and each constructs
 func GetReplacementMarkers(str string) ([]types.Replacement, error) {
-	var replacements []types.Replacement
-	lines := strings.Split(str, "\n")
-	for _, line := range lines {
-		startIndex := 0
-		for {
-			openIndex := strings.Index(line[startIndex:], "{{")
-			if openIndex == -1 {
-				break
-			}
-			startIndex += openIndex + 2
-			endIndex := strings.Index(line[startIndex:], "}}")
-			if endIndex == -1 {
-				return nil, errors.New("Invalid syntax: opening delimiter '{{' found without closing delimiter '}}'")
-			}
-			endIndex += startIndex
-			content := line[startIndex:endIndex]
-			replacementArray := strings.Split(content, ":")
-			if len(replacementArray) < 2 {
-				return nil, errors.New("Invalid syntax: replacementArray must have at least 2 entries")
-			}
-			indentCount := countIndent(line[:startIndex-2], startIndex-2)
-			replacement := types.Replacement{
-				Type:    replacementArray[0],
-				Value:   replacementArray[1],
-				Indents: indentCount,
-				Target:  content,
-			}
-			if len(replacementArray) > 2 {
-				replacement.Options = replacementArray[2:]
-			}
-			replacements = append(replacements, replacement)
-			startIndex = endIndex + 2
-		}
+	nodes, err := ParseTemplate(str)
+	if nil != err {
+		return nil, err
 	}
-	return replacements, nil
+	return collectTemplateMarkers(nodes), nil
 }
 
 func countIndent(str string, startIndex int) int {
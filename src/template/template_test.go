@@ -0,0 +1,120 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/voodooEntity/gomcmf/src/converter"
+	"github.com/voodooEntity/gomcmf/src/types"
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+func TestBuildPageGroupNavLinks(t *testing.T) {
+	defer SetConverterOptions(converter.Options{})
+	pagegroup := types.Pagegroup{
+		Ident: "/",
+		Entries: []types.Page{
+			{Name: "Home", Filename: "1.Home.md", UrlName: "Home", Type: "md"},
+			{Name: "GitHub", Type: "link", Content: "https://github.com/x\n"},
+			{Name: "Docs", Type: "link", Content: "https://example.com/docs"},
+		},
+	}
+	tests := []struct {
+		name    string
+		options converter.Options
+		want    []string
+	}{
+		{
+			name:    "default policy opens external links in a new tab",
+			options: converter.Options{Base: "https://example.com"},
+			want: []string{
+				"<li class='active'><a href='Home.html'>Home</a></li>",
+				"<li><a href='https://github.com/x' target='_blank'>GitHub</a></li>",
+				"<li><a href='https://example.com/docs'>Docs</a></li>",
+			},
+		},
+		{
+			name: "configured policy",
+			options: converter.Options{Base: "https://example.com", LinkPolicy: &types.LinkPolicy{
+				Rules: []types.LinkRule{{Match: converter.LinkRuleMatchExternal, Rel: "noopener"}},
+			}},
+			want: []string{
+				"<li><a href='https://github.com/x' rel='noopener'>GitHub</a></li>",
+				"<li><a href='https://example.com/docs'>Docs</a></li>",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SetConverterOptions(test.options)
+			nav := BuildPageGroupNav(pagegroup, 0, pagegroup.Entries[0], "/", nil)
+			for _, want := range test.want {
+				if !strings.Contains(nav, want) {
+					t.Errorf("expected %q in %q", want, nav)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderPageFormat(t *testing.T) {
+	setTestShortcodes()
+	defer SetConverterOptions(converter.Options{})
+	SetConverterOptions(converter.Options{MathRenderer: converter.MathRendererClient})
+	page := types.Page{
+		Name:    "Home",
+		Type:    "md",
+		Content: "# {{var:title}}\n\n{{< note title=\"Hint\" >}}\n\n$a_1 * b_1$ and {{nav:main}}",
+	}
+	variables := map[string]string{"title": "Welcome"}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{converter.FormatGemtext, "# Welcome\nHint\n$a_1 * b_1$ and\n"},
+		{converter.FormatText, "Welcome\n=======\n\nHint\n\n$a_1 * b_1$ and\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			if got := RenderPageFormat(page, test.format, 80, variables, nil, ""); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderPageReportsErrors(t *testing.T) {
+	setTestShortcodes()
+	defer util.ResetReportedErrors()
+	dir := t.TempDir()
+	source := "---\ntitle: T\n---\n# T\n\nText {{< nope x=\"1\" >}} and {{var:missing}}\n\n{{nav:nogroup}}\n"
+	if err := os.WriteFile(filepath.Join(dir, "1.Page.md"), []byte(source), 0644); nil != err {
+		t.Fatal(err)
+	}
+	_, content := ParseFrontMatter(source)
+	page := types.Page{Name: "Page", Filename: "1.Page.md", Path: dir, Type: "md", Content: content}
+	nodes, _ := ParseTemplate("{{render:content}}")
+
+	util.ResetReportedErrors()
+	html := RenderPage(page, Layout{Nodes: nodes}, nil, nil, "/")
+	if !strings.Contains(html, "{{< nope x=\"1\" >}}") {
+		t.Errorf("expected the unknown shortcode to be kept in %q", html)
+	}
+	file := filepath.Join(dir, "1.Page.md")
+	want := []util.SourceError{
+		{File: file, Line: 6, Column: 6, Message: "Error expanding shortcodes - error: 'unknown shortcode 'nope''"},
+		{File: file, Line: 6, Column: 29, Message: "Tryied to render non existing variable 'missing'"},
+		{File: file, Line: 8, Column: 1, Message: "Tryied to render non existing pagegroup 'nogroup'"},
+	}
+	got := util.GetReportedErrors()
+	if len(want) != len(got) {
+		t.Fatalf("expected %v but got %v", want, got)
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("expected %q but got %q", want[i].Error(), got[i].Error())
+		}
+	}
+}
@@ -0,0 +1,529 @@
+package template
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/voodooEntity/gomcmf/src/converter"
+	"github.com/voodooEntity/gomcmf/src/types"
+	"github.com/voodooEntity/gomcmf/src/util"
+)
+
+// kinds of template nodes
+const NodeText = "text"
+const NodeMarker = "marker"
+const NodeIf = "if"
+const NodeEach = "each"
+
+// kinds of control tags closing or splitting a node
+const tagElse = "else"
+const tagEndIf = "endif"
+const tagEnd = "end"
+
+const controlTagLineRxp = `^\s*\{\{(?:(?:if|each):[^}]+|else|endif|end)\}\}\s*$`
+const adjacentListsRxp = `\n\s*</ul>\s*<ul>`
+
+// control tag lines are handled before all built-in block parsers
+const priorityControlTags = converter.PriorityHeading + 100
+
+func init() {
+	converter.RegisterBlockParser(converter.NewBlockParser("controlTags", priorityControlTags, openControlTag))
+}
+
+// TemplateNode is a node of a parsed template. Text nodes hold plain text,
+// marker nodes a {{type:value:options}} marker. If nodes render Children
+// if their Marker has a non empty value and Else otherwise, each nodes
+// render Children for every item of the list their Marker points to.
+type TemplateNode struct {
+	Kind     string
+	Text     string
+	Marker   types.Replacement
+	Children []TemplateNode
+	Else     []TemplateNode
+}
+
+// RenderContext is everything markers of a template can refer to. Item is
+// the current item of the innermost each loop. With VarsOnly all markers
+// except var and item render empty, as used for non html formats.
+type RenderContext struct {
+	Variables  map[string]string
+	PageGroups map[string]types.Pagegroup
+	Content    string
+	Page       types.Page
+	GroupIdent string
+	Item       map[string]string
+	VarsOnly   bool
+}
+
+// templateToken is a piece of a template as found by tokenizeTemplate
+type templateToken struct {
+	Kind   string
+	Text   string
+	Marker types.Replacement
+}
+
+// ParseTemplate parses a template into a tree of text, marker, if and each
+// nodes. Control tags standing alone on their line are removed together
+// with the line so they don't leave empty lines behind.
+func ParseTemplate(str string) ([]TemplateNode, error) {
+	return parseTemplate(str, "", nil)
+}
+
+// ParseTemplateFile parses a template like ParseTemplate, the markers and
+// errors carry the given file name and their line and column
+func ParseTemplateFile(str string, file string) ([]TemplateNode, error) {
+	return parseTemplate(str, file, nil)
+}
+
+// parseTemplate parses a template, markers in the given code regions are
+// kept as they are documentation. Only markdown and its converted html have
+// code regions, the <pre> and <code> of hand-written html may contain
+// markers.
+func parseTemplate(str string, file string, codeRegions [][]int) ([]TemplateNode, error) {
+	tokens, err := tokenizeTemplate(str, file, codeRegions)
+	if nil != err {
+		return nil, err
+	}
+	pos := 0
+	nodes, closing, err := parseTemplateNodes(tokens, &pos)
+	if nil != err {
+		return nil, err
+	}
+	if nil != closing {
+		return nil, newMarkerError(*closing, "Invalid syntax: '{{"+closing.Target+"}}' without opening tag")
+	}
+	return nodes, nil
+}
+
+// code blocks of the html converted from markdown are documentation,
+// markers inside of them are left as is
+const templateCodeRegionRxp = "(?s)<pre[\\s>].*?</pre>|<code[\\s>].*?</code>"
+
+// escape forms for literal braces, \{{ for a single {{ and {{{{...}}}}
+// for everything in between
+const escapedOpenDelimiter = "\\{{"
+const rawOpenDelimiter = "{{{{"
+const rawCloseDelimiter = "}}}}"
+
+const multilineMarkerRxp = `\{\{[^{}]*\n[^{}]*\}\}`
+const blankLineRxp = `\n\s*\n`
+
+func tokenizeTemplate(str string, file string, codeRegions [][]int) ([]templateToken, error) {
+	var tokens []templateToken
+	text := ""
+	pos := 0
+	for {
+		openIndex := strings.Index(str[pos:], "{{")
+		if -1 == openIndex {
+			break
+		}
+		openIndex += pos
+
+		// markers in code blocks are kept
+		if regionEnd := getRegionEnd(codeRegions, openIndex); -1 != regionEnd {
+			text = text + str[pos:regionEnd]
+			pos = regionEnd
+			continue
+		}
+		// \{{ is a literal {{
+		if strings.HasSuffix(str[:openIndex+2], escapedOpenDelimiter) {
+			text = text + str[pos:openIndex-1] + "{{"
+			pos = openIndex + 2
+			continue
+		}
+		// shortcodes left by their expansion are invalid and reported already
+		if strings.HasPrefix(str[openIndex:], shortcodeOpenDelimiter) {
+			if closeIndex := strings.Index(str[openIndex:], shortcodeCloseDelimiter); -1 != closeIndex {
+				closeIndex += openIndex + len(shortcodeCloseDelimiter)
+				text = text + str[pos:closeIndex]
+				pos = closeIndex
+				continue
+			}
+		}
+		// {{{{...}}}} is kept literally as {{...}}
+		if strings.HasPrefix(str[openIndex:], rawOpenDelimiter) {
+			closeIndex := strings.Index(str[openIndex+4:], rawCloseDelimiter)
+			if -1 == closeIndex {
+				line, column := getSourcePosition(str, openIndex)
+				return nil, util.SourceError{File: file, Line: line, Column: column, Message: "Invalid syntax: opening delimiter '{{{{' found without closing delimiter '}}}}'"}
+			}
+			closeIndex += openIndex + 4
+			text = text + str[pos:openIndex] + "{{" + str[openIndex+4:closeIndex] + "}}"
+			pos = closeIndex + 4
+			continue
+		}
+
+		line, column := getSourcePosition(str, openIndex)
+		closeIndex := strings.Index(str[openIndex+2:], "}}")
+		if -1 == closeIndex {
+			return nil, util.SourceError{File: file, Line: line, Column: column, Message: "Invalid syntax: opening delimiter '{{' found without closing delimiter '}}'"}
+		}
+		closeIndex += openIndex + 2
+		content := str[openIndex+2 : closeIndex]
+		endIndex := closeIndex + 2
+		textEnd := openIndex
+
+		token, err := newTemplateToken(content, countIndent(str[:openIndex], openIndex), types.Replacement{File: file, Line: line, Column: column})
+		if nil != err {
+			return nil, err
+		}
+
+		// control tags alone on their line take the line with them
+		if NodeMarker != token.Kind {
+			lineStart := strings.LastIndex(str[:openIndex], "\n") + 1
+			lineEnd := strings.Index(str[endIndex:], "\n")
+			if -1 == lineEnd {
+				lineEnd = len(str)
+			} else {
+				lineEnd += endIndex + 1
+			}
+			if lineStart >= pos && "" == strings.TrimSpace(str[lineStart:openIndex]) && "" == strings.TrimSpace(str[endIndex:lineEnd]) {
+				textEnd = lineStart
+				endIndex = lineEnd
+			}
+		}
+
+		text = text + str[pos:textEnd]
+		if "" != text {
+			tokens = append(tokens, templateToken{Kind: NodeText, Text: text})
+			text = ""
+		}
+		tokens = append(tokens, token)
+		pos = endIndex
+	}
+	text = text + str[pos:]
+	if "" != text {
+		tokens = append(tokens, templateToken{Kind: NodeText, Text: text})
+	}
+	return tokens, nil
+}
+
+// JoinMultilineMarkers puts markers spanning several lines onto a single
+// line, used before the markdown conversion which works line by line. A
+// blank line ends a marker, markers in code and escaped ones are kept.
+func JoinMultilineMarkers(str string) string {
+	if !strings.Contains(str, "{{") {
+		return str
+	}
+	codeRegions := getMarkdownCodeRegions(str)
+	blankLine := regexp.MustCompile(blankLineRxp)
+	var out strings.Builder
+	last := 0
+	for _, loc := range regexp.MustCompile(multilineMarkerRxp).FindAllStringIndex(str, -1) {
+		// the marker regex can't cross braces, a match right after a
+		// backslash or brace is part of \{{ or {{{{
+		if -1 != getRegionEnd(codeRegions, loc[0]) || blankLine.MatchString(str[loc[0]:loc[1]]) {
+			continue
+		}
+		if 0 < loc[0] && ('\\' == str[loc[0]-1] || '{' == str[loc[0]-1]) {
+			continue
+		}
+		out.WriteString(str[last:loc[0]])
+		out.WriteString(strings.Join(strings.Fields(str[loc[0]:loc[1]]), " "))
+		last = loc[1]
+	}
+	out.WriteString(str[last:])
+	return out.String()
+}
+
+// getCodeRegions returns the start and end of all code blocks of the html
+// converted from markdown
+func getCodeRegions(str string) [][]int {
+	if !strings.Contains(str, "<pre") && !strings.Contains(str, "<code") {
+		return nil
+	}
+	return regexp.MustCompile(templateCodeRegionRxp).FindAllStringIndex(str, -1)
+}
+
+// newTemplateToken parses the content of a tag at the position given by
+// the location. Markers may span several lines, whitespace around the
+// parts of a marker is ignored.
+func newTemplateToken(content string, indents int, location types.Replacement) (templateToken, error) {
+	location.Target = content
+	trimmed := strings.TrimSpace(content)
+	switch trimmed {
+	case tagElse, tagEndIf, tagEnd:
+		return templateToken{Kind: trimmed, Text: content, Marker: location}, nil
+	}
+	kind := NodeMarker
+	marker := trimmed
+	if strings.HasPrefix(trimmed, NodeIf+":") || strings.HasPrefix(trimmed, NodeEach+":") {
+		parts := strings.SplitN(trimmed, ":", 2)
+		kind = parts[0]
+		marker = parts[1]
+	}
+	replacementArray := strings.Split(marker, ":")
+	for i := range replacementArray {
+		replacementArray[i] = strings.TrimSpace(replacementArray[i])
+	}
+	if len(replacementArray) < 2 {
+		return templateToken{}, newMarkerError(location, "Invalid syntax: replacementArray must have at least 2 entries in '{{"+content+"}}'")
+	}
+	replacement := location
+	replacement.Type = replacementArray[0]
+	replacement.Value = replacementArray[1]
+	replacement.Indents = indents
+	if len(replacementArray) > 2 {
+		replacement.Options = replacementArray[2:]
+	}
+	return templateToken{Kind: kind, Text: content, Marker: replacement}, nil
+}
+
+// parseTemplateNodes parses tokens until a closing or else tag, which is
+// returned to the caller
+func parseTemplateNodes(tokens []templateToken, pos *int) ([]TemplateNode, *types.Replacement, error) {
+	var nodes []TemplateNode
+	for *pos < len(tokens) {
+		token := tokens[*pos]
+		*pos++
+		switch token.Kind {
+		case NodeText:
+			nodes = append(nodes, TemplateNode{Kind: NodeText, Text: token.Text})
+		case NodeMarker:
+			nodes = append(nodes, TemplateNode{Kind: NodeMarker, Marker: token.Marker})
+		case NodeIf:
+			node := TemplateNode{Kind: NodeIf, Marker: token.Marker}
+			children, closing, err := parseTemplateNodes(tokens, pos)
+			if nil != err {
+				return nil, nil, err
+			}
+			node.Children = children
+			if nil != closing && tagElse == strings.TrimSpace(closing.Target) {
+				node.Else, closing, err = parseTemplateNodes(tokens, pos)
+				if nil != err {
+					return nil, nil, err
+				}
+			}
+			if nil == closing || tagEndIf != strings.TrimSpace(closing.Target) {
+				return nil, nil, newMarkerError(token.Marker, "Invalid syntax: '{{"+token.Text+"}}' is not closed by '{{endif}}'")
+			}
+			nodes = append(nodes, node)
+		case NodeEach:
+			node := TemplateNode{Kind: NodeEach, Marker: token.Marker}
+			children, closing, err := parseTemplateNodes(tokens, pos)
+			if nil != err {
+				return nil, nil, err
+			}
+			if nil == closing || tagEnd != strings.TrimSpace(closing.Target) {
+				return nil, nil, newMarkerError(token.Marker, "Invalid syntax: '{{"+token.Text+"}}' is not closed by '{{end}}'")
+			}
+			node.Children = children
+			nodes = append(nodes, node)
+		default:
+			closing := token.Marker
+			return nodes, &closing, nil
+		}
+	}
+	return nodes, nil, nil
+}
+
+// RenderTemplate renders a parsed template
+func RenderTemplate(nodes []TemplateNode, context RenderContext) string {
+	var out strings.Builder
+	for _, node := range nodes {
+		switch node.Kind {
+		case NodeText:
+			out.WriteString(node.Text)
+		case NodeMarker:
+			out.WriteString(renderTemplateMarker(node.Marker, context))
+		case NodeIf:
+			if isTemplateConditionTrue(node.Marker, context) {
+				out.WriteString(RenderTemplate(node.Children, context))
+			} else {
+				out.WriteString(RenderTemplate(node.Else, context))
+			}
+		case NodeEach:
+			for _, item := range getTemplateItems(node.Marker, context) {
+				itemContext := context
+				itemContext.Item = item
+				out.WriteString(RenderTemplate(node.Children, itemContext))
+			}
+		}
+	}
+	return out.String()
+}
+
+func renderTemplateMarker(marker types.Replacement, context RenderContext) string {
+	if "item" == marker.Type {
+		if nil == context.Item {
+			reportMarkerError(marker, "Tryied to render '{{"+marker.Target+"}}' outside of an each loop")
+			return ""
+		}
+		return context.Item[marker.Value]
+	}
+	if context.VarsOnly {
+		if "var" != marker.Type {
+			return ""
+		}
+		return context.Variables[marker.Value]
+	}
+	return GetReplacementContent(marker, context.Variables, context.PageGroups, context.Content, context.Page, context.GroupIdent)
+}
+
+// isTemplateConditionTrue checks whether the marker of an if has a non
+// empty value. Missing variables, item fields and front matter keys are
+// false instead of an error.
+func isTemplateConditionTrue(marker types.Replacement, context RenderContext) bool {
+	switch marker.Type {
+	case "var":
+		if val, ok := GetPagerValue(context.PageGroups, context.Page, context.GroupIdent, marker.Value); ok {
+			return "" != val
+		}
+		return "" != context.Variables[marker.Value]
+	case "item":
+		return "" != context.Item[marker.Value]
+	case "data":
+		val, ok := GetDataValue(marker.Value)
+		return ok && "" != formatDataValue(val)
+	case "page":
+		if val, ok := context.Page.Meta[marker.Value]; ok {
+			return "" != val
+		}
+		if !util.StringInArray([]string{"name", "url", "excerpt", "date", "wordcount", "charcount", "readingtime"}, marker.Value) {
+			return false
+		}
+	}
+	return "" != strings.TrimSpace(renderTemplateMarker(marker, context))
+}
+
+// getTemplateItems returns the items an each loop iterates. Supported are
+// the pages of a group like {{each:group:/blog:date:desc}}, taking the
+// same options as nav markers, comma separated front matter values of
+// the current page like {{each:page:tags}} and data like
+// {{each:data:releases}}.
+func getTemplateItems(marker types.Replacement, context RenderContext) []map[string]string {
+	var items []map[string]string
+	switch marker.Type {
+	case "group":
+		group, ok := context.PageGroups[marker.Value]
+		if !ok {
+			reportMarkerError(marker, "Tryied to iterate non existing pagegroup '"+marker.Value+"'")
+			return nil
+		}
+		options, err := parseNavOptions(marker.Options)
+		if nil != err {
+			reportMarkerError(marker, err.Error())
+		}
+		for _, page := range getNavEntries(group.Entries, options) {
+			items = append(items, getPageItem(page, group.Ident))
+		}
+	case "page":
+		for _, value := range strings.Split(context.Page.Meta[marker.Value], ",") {
+			if value = strings.TrimSpace(value); "" != value {
+				items = append(items, map[string]string{"value": value})
+			}
+		}
+	case "data":
+		items = getDataItems(marker)
+	default:
+		reportMarkerError(marker, "Tryied to iterate unknown list '"+marker.Type+":"+marker.Value+"'")
+	}
+	return items
+}
+
+// getPageItem returns the fields of a page available as {{item:field}},
+// the front matter keys included
+func getPageItem(page types.Page, ident string) map[string]string {
+	item := make(map[string]string)
+	for key, value := range page.Meta {
+		item[key] = value
+	}
+	item["name"] = page.Name
+	item["type"] = page.Type
+	item["excerpt"] = page.Excerpt
+	item["date"] = FormatDate(page.Date, "")
+	item["wordcount"] = strconv.Itoa(page.WordCount)
+	item["charcount"] = strconv.Itoa(page.CharCount)
+	item["readingtime"] = strconv.Itoa(GetReadingTime(page))
+	if "link" == page.Type {
+		item["url"] = strings.TrimSpace(page.Content)
+	} else {
+		item["url"] = buildInternalUrl(ident, page)
+	}
+	return item
+}
+
+// collectTemplateMarkers returns all markers of a parsed template
+// including the ones inside of if and each nodes
+func collectTemplateMarkers(nodes []TemplateNode) []types.Replacement {
+	var markers []types.Replacement
+	for _, node := range nodes {
+		switch node.Kind {
+		case NodeMarker:
+			markers = append(markers, node.Marker)
+		case NodeIf, NodeEach:
+			markers = append(markers, collectTemplateMarkers(node.Children)...)
+			markers = append(markers, collectTemplateMarkers(node.Else)...)
+		}
+	}
+	return markers
+}
+
+// openControlTag takes if and each tags standing alone on their line out of
+// the markdown flow, they end the current paragraph and the blocks between
+// them are converted like any other block
+func openControlTag(content *converter.Content) bool {
+	if !strings.HasPrefix(strings.TrimSpace(content.State.CurrentLineString), "{{") {
+		return false
+	}
+	if !regexp.MustCompile(controlTagLineRxp).MatchString(content.State.CurrentLineString) {
+		return false
+	}
+	content.CloseParagraph()
+	content.State.CurrentLineString = "\n" + strings.TrimSpace(content.State.CurrentLineString)
+	return true
+}
+
+// joinAdjacentLists joins lists which directly follow each other into one,
+// as left behind by list items within each loops or if constructs of
+// converted markdown
+func joinAdjacentLists(html string) string {
+	return regexp.MustCompile(adjacentListsRxp).ReplaceAllString(html, "")
+}
+
+// getSourcePosition returns the 1 based line and column of an index
+func getSourcePosition(str string, index int) (int, int) {
+	lineStart := strings.LastIndex(str[:index], "\n") + 1
+	return strings.Count(str[:index], "\n") + 1, utf8.RuneCountInString(str[lineStart:index]) + 1
+}
+
+// RelocateTemplateMarkers sets the positions of all markers to the ones in
+// the original source, for templates which were converted or expanded
+// before parsing. Markers are searched in order, the ones not found in the
+// source (like the ones of included partials) lose their position.
+func RelocateTemplateMarkers(nodes []TemplateNode, source string, file string) {
+	offset := 0
+	relocateTemplateMarkers(nodes, source, file, &offset)
+}
+
+func relocateTemplateMarkers(nodes []TemplateNode, source string, file string, offset *int) {
+	for i := range nodes {
+		if NodeText == nodes[i].Kind {
+			continue
+		}
+		target := "{{" + nodes[i].Marker.Target + "}}"
+		if index := strings.Index(source[*offset:], target); -1 != index {
+			*offset = *offset + index
+			nodes[i].Marker.File = file
+			nodes[i].Marker.Line, nodes[i].Marker.Column = getSourcePosition(source, *offset)
+			*offset = *offset + len(target)
+		} else {
+			nodes[i].Marker.File = file
+			nodes[i].Marker.Line, nodes[i].Marker.Column = 0, 0
+		}
+		relocateTemplateMarkers(nodes[i].Children, source, file, offset)
+		relocateTemplateMarkers(nodes[i].Else, source, file, offset)
+	}
+}
+
+func newMarkerError(marker types.Replacement, message string) util.SourceError {
+	return util.SourceError{File: marker.File, Line: marker.Line, Column: marker.Column, Message: message}
+}
+
+// reportMarkerError collects an error of a marker, the build goes on and
+// all errors are shown at its end
+func reportMarkerError(marker types.Replacement, message string) {
+	util.ReportError(newMarkerError(marker, message))
+}
@@ -0,0 +1,199 @@
+package template
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/voodooEntity/gomcmf/src/types"
+)
+
+func getTestTreeGroups() map[string]types.Pagegroup {
+	return map[string]types.Pagegroup{
+		"/blog": {Ident: "/blog", Entries: []types.Page{
+			{Name: "Old", UrlName: "Old", Filename: "1.Old.md", Type: "md", Sequence: 1, Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{Name: "New", UrlName: "New", Filename: "2.New.md", Type: "md", Sequence: 2, Date: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Meta: map[string]string{"author": "Ann"}},
+		}},
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	context := RenderContext{
+		Variables:  map[string]string{"title": "Site", "empty": ""},
+		PageGroups: getTestTreeGroups(),
+		Page:       types.Page{Name: "Page", Meta: map[string]string{"tags": "a, b", "draft": ""}},
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"marker", "<h1>{{var:title}}</h1>", "<h1>Site</h1>"},
+		{"if", "{{if:var:title}}yes{{endif}}", "yes"},
+		{"if else", "{{if:var:empty}}yes{{else}}no{{endif}}", "no"},
+		{"missing values are false", "{{if:var:nope}}yes{{endif}}{{if:page:nope}}yes{{endif}}{{if:page:draft}}yes{{endif}}", ""},
+		{"each group", "{{each:group:/blog:date:desc}}<a href='{{item:url}}'>{{item:name}}</a>{{end}}", "<a href='blog/New.html'>New</a><a href='blog/Old.html'>Old</a>"},
+		{"each with if", "{{each:group:/blog}}{{item:name}}{{if:item:author}} by {{item:author}}{{endif}};{{end}}", "Old;New by Ann;"},
+		{"each front matter list", "{{each:page:tags}}[{{item:value}}]{{end}}", "[a][b]"},
+		{"control lines are removed", "<ul>\n  {{each:page:tags}}\n  <li>{{item:value}}</li>\n  {{end}}\n</ul>", "<ul>\n  <li>a</li>\n  <li>b</li>\n</ul>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodes, err := ParseTemplate(test.template)
+			if nil != err {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if got := RenderTemplate(nodes, context); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{"{{if:var:a}}x", "f.html:1:1: Invalid syntax: '{{if:var:a}}' is not closed by '{{endif}}'"},
+		{"a\n  {{each:page:tags}}x{{endif}}", "f.html:2:3: Invalid syntax: '{{each:page:tags}}' is not closed by '{{end}}'"},
+		{"x{{end}}", "f.html:1:2: Invalid syntax: '{{end}}' without opening tag"},
+		{"ä {{var:a", "f.html:1:3: Invalid syntax: opening delimiter '{{' found without closing delimiter '}}'"},
+		{"{{nope}}", "must have at least 2 entries"},
+	}
+	for _, test := range tests {
+		t.Run(test.template, func(t *testing.T) {
+			_, err := ParseTemplateFile(test.template, "f.html")
+			if nil == err || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("expected error %q but got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRenderPageControlTagsInMarkdown(t *testing.T) {
+	page := types.Page{
+		Name: "Posts",
+		Type: "md",
+		Meta: map[string]string{"draft": ""},
+		Content: "# Posts\n\nLatest posts:\n{{each:group:/blog:date:desc}}\n- [{{item:name}}]({{item:url}})\n{{end}}\n" +
+			"{{if:page:draft}}\nDraft\n{{else}}\n- [All posts](blog.html)\n{{endif}}\nThe end",
+	}
+	nodes, _ := ParseTemplate("{{render:content}}")
+	layout := Layout{Nodes: nodes}
+	html := RenderPage(page, layout, nil, getTestTreeGroups(), "/")
+
+	want := "<ul>\n      <li><a href='blog/New.html'>New</a></li>\n      <li><a href='blog/Old.html'>Old</a></li>\n      <li><a href='blog.html'>All posts</a></li>\n    </ul>"
+	if !strings.Contains(html, want) {
+		t.Errorf("expected one list %q in %q", want, html)
+	}
+	if 1 != strings.Count(html, "<ul>") || strings.Contains(html, "<br>") || strings.Contains(html, "{{") {
+		t.Errorf("expected a single list without line breaks and markers in %q", html)
+	}
+	// no block element may end up within a paragraph
+	for _, part := range strings.Split(html, "<p>")[1:] {
+		paragraph := strings.SplitN(part, "</p>", 2)[0]
+		if strings.Contains(paragraph, "<ul>") || strings.Contains(paragraph, "<li>") {
+			t.Errorf("expected no list within paragraph %q", paragraph)
+		}
+	}
+	for _, text := range []string{"Latest posts:", "The end"} {
+		if !strings.Contains(html, "<p>\n"+text+"\n  </p>") {
+			t.Errorf("expected paragraph %q in %q", text, html)
+		}
+	}
+}
+
+func TestJoinMultilineMarkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"joined", "a {{nav:/blog:\n    date:desc:\n    limit=5}} b", "a {{nav:/blog: date:desc: limit=5}} b"},
+		{"single line", "{{var:title}}\n{{var:x}}", "{{var:title}}\n{{var:x}}"},
+		{"blank line ends the marker", "{{var:title\n\nnext}}", "{{var:title\n\nnext}}"},
+		{"whitespace only line ends the marker", "{{var:title\n  \nnext}}", "{{var:title\n  \nnext}}"},
+		{"escaped", "\\{{var:\ntitle}}", "\\{{var:\ntitle}}"},
+		{"raw", "{{{{ a\n b }}}}", "{{{{ a\n b }}}}"},
+		{"fenced code", "```\n{{var:\ntitle}}\n```", "```\n{{var:\ntitle}}\n```"},
+		{"inline code", "`{{var: title}}` {{var:\ntitle}}", "`{{var: title}}` {{var: title}}"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := JoinMultilineMarkers(test.content); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTemplateEscapes(t *testing.T) {
+	context := RenderContext{Variables: map[string]string{"title": "Site"}}
+	tests := []struct {
+		name     string
+		template string
+		markdown bool
+		want     string
+	}{
+		{"escaped marker", "\\{{var:title}} {{var:title}}", false, "{{var:title}} Site"},
+		{"raw", "{{{{ var:title }}}}", false, "{{ var:title }}"},
+		{"multi-line marker", "{{var:\n  title}}", false, "Site"},
+		{"code of markdown is kept", "<pre><code>{{var:title}}</code></pre><code>{{var:title}}</code>{{var:title}}", true, "<pre><code>{{var:title}}</code></pre><code>{{var:title}}</code>Site"},
+		{"code of html is rendered", "<pre>{{var:title}}</pre><code>{{var:title}}</code>", false, "<pre>Site</pre><code>Site</code>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var codeRegions [][]int
+			if test.markdown {
+				codeRegions = getCodeRegions(test.template)
+			}
+			nodes, err := parseTemplate(test.template, "", codeRegions)
+			if nil != err {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if got := RenderTemplate(nodes, context); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+	if _, err := ParseTemplate("{{{{ x"); nil == err {
+		t.Errorf("expected an error for an unclosed raw delimiter")
+	}
+}
+
+func TestRenderPageMarkerSyntax(t *testing.T) {
+	page := types.Page{
+		Name:    "Docs",
+		Type:    "md",
+		Content: "# {{var:\n  title}}\n\nUse `{{var:title}}` or \\{{var:title}}.\n\n```\n{{var:title}}\n```\n",
+	}
+	nodes, _ := ParseTemplate("{{render:content}}")
+	html := RenderPage(page, Layout{Nodes: nodes}, map[string]string{"title": "Site"}, nil, "/")
+	for _, want := range []string{">Site</h1>", "<code>{{var:title}}</code> or {{var:title}}.", "{{var:title}}\n"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in %q", want, html)
+		}
+	}
+	if strings.Contains(html, "\\{{") {
+		t.Errorf("expected the escape to be removed in %q", html)
+	}
+}
+
+func TestRelocateTemplateMarkers(t *testing.T) {
+	source := "<h1>{{var:title}}</h1>\n{{include:x.html}}\n  {{if:var:a}}{{var:b}}{{endif}}"
+	nodes, err := ParseTemplate("<h1>{{var:title}}</h1>\n{{var:fromPartial}}\n{{if:var:a}}{{var:b}}{{endif}}")
+	if nil != err {
+		t.Fatalf("unexpected error %s", err)
+	}
+	RelocateTemplateMarkers(nodes, source, "f.html")
+	var got []string
+	for _, marker := range collectTemplateMarkers(nodes) {
+		got = append(got, marker.Value+"@"+strconv.Itoa(marker.Line)+":"+strconv.Itoa(marker.Column))
+	}
+	want := "title@1:5 fromPartial@0:0 b@3:15"
+	if want != strings.Join(got, " ") {
+		t.Errorf("expected %q but got %q", want, strings.Join(got, " "))
+	}
+}
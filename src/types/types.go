@@ -1,8 +1,12 @@
 package types
 
+import "time"
+
 type Args struct {
 	Command  string
 	Input    string
+	Output   string
+	Template string
 	Name     string
 	Type     string
 	Target   string
@@ -17,19 +21,48 @@ type Replacement struct {
 	Options []string
 	Indents int
 	Target  string
+	File    string
+	Line    int
+	Column  int
 }
 
 type Page struct {
-	Filename string
-	UrlName  string
-	Name     string
-	Path     string
-	Type     string
-	Content  string
-	Sequence int
+	Filename  string
+	UrlName   string
+	Name      string
+	Path      string
+	Type      string
+	Content   string
+	Html      string
+	Excerpt   string
+	Meta      map[string]string
+	Date      time.Time
+	Sequence  int
+	WordCount int
+	CharCount int
 }
 
 type Pagegroup struct {
 	Ident   string
 	Entries []Page
 }
+
+type BlockRendererConfig struct {
+	Open    string   `json:"open"`
+	Close   string   `json:"close"`
+	Raw     bool     `json:"raw"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+}
+
+type LinkPolicy struct {
+	InternalDomains []string   `json:"internalDomains"`
+	Rules           []LinkRule `json:"rules"`
+}
+
+type LinkRule struct {
+	Match  string `json:"match"`
+	Rel    string `json:"rel"`
+	Target string `json:"target"`
+	Class  string `json:"class"`
+}
@@ -0,0 +1,109 @@
+package util
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SourceError is an error in a source file like a template or page. Line
+// and Column are 1 based, 0 if unknown.
+type SourceError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+var reportedErrors []SourceError
+
+func (self SourceError) Error() string {
+	location := self.File
+	if "" != location && 0 < self.Line {
+		location = location + ":" + strconv.Itoa(self.Line) + ":" + strconv.Itoa(self.Column)
+	}
+	if "" == location {
+		return self.Message
+	}
+	return location + ": " + self.Message
+}
+
+// CodeFrame returns the erroneous line with one line of context around it
+// and a caret pointing at the column, empty if the file can't be read
+func (self SourceError) CodeFrame() string {
+	if "" == self.File || 0 >= self.Line {
+		return ""
+	}
+	data, err := os.ReadFile(self.File)
+	if nil != err {
+		return ""
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if self.Line > len(lines) {
+		return ""
+	}
+	first := self.Line - 1
+	if 1 > first {
+		first = 1
+	}
+	last := self.Line + 1
+	if last > len(lines) {
+		last = len(lines)
+	}
+	width := len(strconv.Itoa(last))
+
+	var frame []string
+	for nr := first; nr <= last; nr++ {
+		prefix := "  "
+		if nr == self.Line {
+			prefix = "> "
+		}
+		number := strconv.Itoa(nr)
+		frame = append(frame, prefix+strings.Repeat(" ", width-len(number))+number+" | "+strings.ReplaceAll(lines[nr-1], "\t", "    "))
+		if nr == self.Line && 0 < self.Column {
+			// tabs are shown as four spaces, the caret has to follow
+			lineRunes := []rune(lines[nr-1])
+			before := string(lineRunes[:minInt(self.Column-1, len(lineRunes))])
+			column := len([]rune(strings.ReplaceAll(before, "\t", "    ")))
+			frame = append(frame, "  "+strings.Repeat(" ", width)+" | "+strings.Repeat(" ", column)+"^")
+		}
+	}
+	return strings.Join(frame, "\n")
+}
+
+// ReportError collects an error to be printed at the end of the build so
+// all errors are shown at once. Identical errors are only reported once.
+func ReportError(err SourceError) {
+	for _, reported := range reportedErrors {
+		if reported == err {
+			return
+		}
+	}
+	reportedErrors = append(reportedErrors, err)
+}
+
+func GetReportedErrors() []SourceError {
+	return reportedErrors
+}
+
+// ResetReportedErrors drops all collected errors
+func ResetReportedErrors() {
+	reportedErrors = nil
+}
+
+// PrintReportedErrors prints all collected errors with their code frames
+func PrintReportedErrors() {
+	for _, err := range reportedErrors {
+		Print("> Error: " + err.Error())
+		if frame := err.CodeFrame(); "" != frame {
+			Print(frame)
+		}
+	}
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  SourceError
+		want string
+	}{
+		{"position", SourceError{File: "a.md", Line: 3, Column: 6, Message: "x"}, "a.md:3:6: x"},
+		{"file only", SourceError{File: "a.md", Message: "x"}, "a.md: x"},
+		{"message only", SourceError{Message: "x"}, "x"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.Error(); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestCodeFrame(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "page.md")
+	if err := os.WriteFile(file, []byte("one\n\ttwo {{x}}\nthree\nfour\n"), 0644); nil != err {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		err  SourceError
+		want string
+	}{
+		{"caret follows tabs", SourceError{File: file, Line: 2, Column: 6}, "  1 | one\n> 2 |     two {{x}}\n    |         ^\n  3 | three"},
+		{"first line", SourceError{File: file, Line: 1, Column: 1}, "> 1 | one\n    | ^\n  2 |     two {{x}}"},
+		{"no line", SourceError{File: file}, ""},
+		{"missing file", SourceError{File: file + ".nope", Line: 1}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.CodeFrame(); test.want != got {
+				t.Errorf("expected %q but got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReportError(t *testing.T) {
+	defer ResetReportedErrors()
+	ResetReportedErrors()
+	ReportError(SourceError{File: "a.md", Line: 1, Message: "x"})
+	ReportError(SourceError{File: "a.md", Line: 1, Message: "x"})
+	ReportError(SourceError{File: "a.md", Line: 2, Message: "x"})
+	if got := len(GetReportedErrors()); 2 != got {
+		t.Errorf("expected 2 errors but got %d", got)
+	}
+}
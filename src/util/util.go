@@ -41,6 +41,13 @@ func Error(text string) {
 	os.Exit(0)
 }
 
+// Fail prints the text and exits with a non zero code, for failed builds
+// which scripts and ci have to notice
+func Fail(text string) {
+	loggerOut.Println(text)
+	os.Exit(1)
+}
+
 func GetHighestIntValFromArray(input []int) int {
 	if 0 == len(input) {
 		return -1
@@ -174,7 +181,7 @@ func BOSSToString(input string) string {
 			Error("Given ord block cant be converted to int '" + ordBlock + "'")
 		}
 
-		result = result + string(ordNr)
+		result = result + string(rune(ordNr))
 	}
 	return result
 }
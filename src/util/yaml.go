@@ -0,0 +1,517 @@
+package util
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParseYaml parses the subset of YAML used for data files: block mappings
+// and sequences nested by indentation with plain or quoted keys, single
+// line flow collections like [a, b] and {a: 1}, single line quoted and
+// plain scalars, | and > block scalars and comments. Everything else, like
+// anchors, aliases, tags and multiple documents, is reported as error.
+// Numbers are returned as float64 and mappings as map[string]interface{}
+// like encoding/json does. Errors are SourceErrors without a file.
+func ParseYaml(content string) (interface{}, error) {
+	parser := yamlParser{}
+	for nr, raw := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if trimmed := strings.TrimLeft(raw, " "); strings.HasPrefix(trimmed, "\t") && "" != strings.TrimSpace(trimmed) {
+			return nil, SourceError{Line: nr + 1, Column: 1, Message: "Invalid yaml: tabs can't be used for indentation"}
+		}
+		text := strings.TrimRight(stripYamlComment(raw), " \t")
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		parser.lines = append(parser.lines, yamlLine{Number: nr + 1, Indent: indent, Text: strings.TrimSpace(text), Raw: raw})
+	}
+	// a leading document marker is allowed, further documents are not
+	if next := parser.next(); nil != next && "---" == next.Text {
+		parser.pos++
+	}
+	next := parser.next()
+	if nil == next {
+		return nil, nil
+	}
+	for _, line := range parser.lines[parser.pos:] {
+		if 0 == line.Indent && ("---" == line.Text || "..." == line.Text) {
+			return nil, line.error("Invalid yaml: multiple documents are not supported")
+		}
+	}
+	value, err := parser.parseNode(next.Indent)
+	if nil != err {
+		return nil, err
+	}
+	if next := parser.next(); nil != next {
+		return nil, next.error("Invalid yaml: unexpected '" + next.Text + "'")
+	}
+	return value, nil
+}
+
+type yamlLine struct {
+	Number int
+	Indent int
+	Text   string
+	Raw    string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+var yamlNumberRxp = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+func (self yamlLine) error(message string) SourceError {
+	return SourceError{Line: self.Number, Column: self.Indent + 1, Message: message}
+}
+
+// next skips empty and comment lines and returns the next line with
+// content, nil at the end
+func (self *yamlParser) next() *yamlLine {
+	for self.pos < len(self.lines) && "" == self.lines[self.pos].Text {
+		self.pos++
+	}
+	if self.pos >= len(self.lines) {
+		return nil
+	}
+	return &self.lines[self.pos]
+}
+
+func (self *yamlParser) parseNode(indent int) (interface{}, error) {
+	line := self.next()
+	if nil == line || line.Indent < indent {
+		return nil, nil
+	}
+	if isYamlSequenceItem(line.Text) {
+		return self.parseSequence(line.Indent)
+	}
+	if _, _, ok := splitYamlMappingEntry(line.Text); ok {
+		return self.parseMapping(line.Indent)
+	}
+	self.pos++
+	return parseYamlValue(line.Text, *line)
+}
+
+func (self *yamlParser) parseSequence(indent int) (interface{}, error) {
+	list := []interface{}{}
+	for {
+		line := self.next()
+		if nil == line || line.Indent < indent {
+			return list, nil
+		}
+		if line.Indent > indent {
+			return nil, line.error("Invalid yaml: unexpected indentation")
+		}
+		if !isYamlSequenceItem(line.Text) {
+			return list, nil
+		}
+		rest := strings.TrimLeft(line.Text[1:], " ")
+		if "" == rest {
+			self.pos++
+			value, err := self.parseChild(indent)
+			if nil != err {
+				return nil, err
+			}
+			list = append(list, value)
+			continue
+		}
+		if _, _, ok := splitYamlMappingEntry(rest); ok || isYamlSequenceItem(rest) {
+			// the entry continues as a nested node at the column of its
+			// content, like "- name: x" followed by "  role: y"
+			line.Indent = line.Indent + len(line.Text) - len(rest)
+			line.Text = rest
+			value, err := self.parseNode(line.Indent)
+			if nil != err {
+				return nil, err
+			}
+			list = append(list, value)
+			continue
+		}
+		self.pos++
+		value, err := self.parseInline(rest, *line, indent)
+		if nil != err {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+}
+
+func (self *yamlParser) parseMapping(indent int) (interface{}, error) {
+	mapping := make(map[string]interface{})
+	for {
+		line := self.next()
+		if nil == line || line.Indent < indent {
+			return mapping, nil
+		}
+		if line.Indent > indent {
+			return nil, line.error("Invalid yaml: unexpected indentation")
+		}
+		if isYamlSequenceItem(line.Text) {
+			return nil, line.error("Invalid yaml: sequence item in a mapping")
+		}
+		key, rest, ok := splitYamlMappingEntry(line.Text)
+		if !ok {
+			return nil, line.error("Invalid yaml: expected 'key: value' but got '" + line.Text + "'")
+		}
+		if _, exists := mapping[key]; exists {
+			return nil, line.error("Invalid yaml: duplicate key '" + key + "'")
+		}
+		self.pos++
+		var value interface{}
+		var err error
+		if "" == rest {
+			value, err = self.parseChild(indent)
+		} else {
+			value, err = self.parseInline(rest, *line, indent)
+		}
+		if nil != err {
+			return nil, err
+		}
+		mapping[key] = value
+	}
+}
+
+// parseChild parses the node below a key or sequence item without an
+// inline value, sequences may be at the same indentation as their key
+func (self *yamlParser) parseChild(indent int) (interface{}, error) {
+	line := self.next()
+	if nil == line {
+		return nil, nil
+	}
+	if line.Indent > indent {
+		return self.parseNode(line.Indent)
+	}
+	if line.Indent == indent && isYamlSequenceItem(line.Text) {
+		return self.parseSequence(indent)
+	}
+	return nil, nil
+}
+
+// parseInline parses the value after a key or sequence item, which is a
+// block scalar, flow collection or scalar
+func (self *yamlParser) parseInline(value string, line yamlLine, indent int) (interface{}, error) {
+	if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+		return self.parseBlockScalar(value, line, indent)
+	}
+	return parseYamlValue(value, line)
+}
+
+// parseBlockScalar reads the lines of a | (literal) or > (folded) block,
+// the indicators - and + strip or keep the trailing newlines
+func (self *yamlParser) parseBlockScalar(header string, line yamlLine, indent int) (interface{}, error) {
+	style := header[:1]
+	chomping := strings.TrimSpace(header[1:])
+	if "" != chomping && "-" != chomping && "+" != chomping {
+		return nil, line.error("Invalid yaml: unsupported block scalar header '" + header + "'")
+	}
+	var lines []string
+	blockIndent := -1
+	for self.pos < len(self.lines) {
+		raw := strings.TrimRight(self.lines[self.pos].Raw, " \t")
+		rawIndent := len(raw) - len(strings.TrimLeft(raw, " "))
+		if "" != raw && rawIndent <= indent {
+			break
+		}
+		if "" != raw && -1 == blockIndent {
+			blockIndent = rawIndent
+		}
+		if "" == raw {
+			lines = append(lines, "")
+		} else if rawIndent < blockIndent {
+			return nil, self.lines[self.pos].error("Invalid yaml: block scalar line is less indented than the first one")
+		} else {
+			lines = append(lines, raw[blockIndent:])
+		}
+		self.pos++
+	}
+
+	// trailing empty lines only matter for the chomping
+	content := len(lines)
+	for 0 < content && "" == lines[content-1] {
+		content--
+	}
+	var text string
+	if "|" == style {
+		text = strings.Join(lines[:content], "\n")
+	} else {
+		text = foldYamlLines(lines[:content])
+	}
+	switch chomping {
+	case "-":
+		return text, nil
+	case "+":
+		return text + strings.Repeat("\n", len(lines)-content+1), nil
+	}
+	if 0 == content {
+		return "", nil
+	}
+	return text + "\n", nil
+}
+
+// foldYamlLines joins the lines of a folded block with spaces, empty lines
+// and more indented lines keep their newlines
+func foldYamlLines(lines []string) string {
+	var builder strings.Builder
+	for i, line := range lines {
+		if 0 < i {
+			previous := lines[i-1]
+			if "" == line || "" == previous || strings.HasPrefix(line, " ") || strings.HasPrefix(previous, " ") {
+				builder.WriteString("\n")
+			} else {
+				builder.WriteString(" ")
+			}
+		}
+		builder.WriteString(line)
+	}
+	return builder.String()
+}
+
+func isYamlSequenceItem(text string) bool {
+	return "-" == text || strings.HasPrefix(text, "- ")
+}
+
+// splitYamlMappingEntry splits "key: value" at the first colon outside of
+// quotes which is followed by a space or the end of the line
+func splitYamlMappingEntry(text string) (string, string, bool) {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return "", "", false
+	}
+	quote := rune(0)
+	for i, char := range text {
+		switch {
+		case 0 != quote:
+			if char == quote {
+				quote = 0
+			}
+		case ('"' == char || '\'' == char) && 0 == i:
+			quote = char
+		case ':' == char && (i+1 == len(text) || ' ' == text[i+1]):
+			key := strings.TrimSpace(text[:i])
+			if unquoted, err := parseYamlQuoted(key); nil == err {
+				key = unquoted
+			}
+			if "" == key {
+				return "", "", false
+			}
+			return key, strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripYamlComment removes a # comment which starts the line or follows a
+// space, ignoring the ones in quotes
+func stripYamlComment(line string) string {
+	quote := rune(0)
+	for i, char := range line {
+		switch {
+		case 0 != quote:
+			if char == quote {
+				quote = 0
+			}
+		case '"' == char || '\'' == char:
+			if 0 == i || strings.ContainsRune(" :-[{,", rune(line[i-1])) {
+				quote = char
+			}
+		case '#' == char && (0 == i || ' ' == line[i-1] || '\t' == line[i-1]):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseYamlValue(value string, line yamlLine) (interface{}, error) {
+	if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+		pos := 0
+		result, err := parseYamlFlow(value, &pos)
+		if nil == err && pos < len(strings.TrimSpace(value)) {
+			err = SourceError{Message: "Invalid yaml: unexpected '" + value[pos:] + "' after flow collection"}
+		}
+		if nil != err {
+			return nil, line.error(err.(SourceError).Message)
+		}
+		return result, nil
+	}
+	if strings.HasPrefix(value, "\"") || strings.HasPrefix(value, "'") {
+		text, err := parseYamlQuoted(value)
+		if nil != err {
+			return nil, line.error(err.(SourceError).Message)
+		}
+		return text, nil
+	}
+	if err := checkYamlPlain(value); nil != err {
+		return nil, line.error(err.(SourceError).Message)
+	}
+	return parseYamlPlain(value), nil
+}
+
+// parseYamlFlow parses a flow collection starting at pos
+func parseYamlFlow(value string, pos *int) (interface{}, error) {
+	skipYamlSpaces(value, pos)
+	if *pos >= len(value) {
+		return nil, SourceError{Message: "Invalid yaml: unexpected end of flow collection"}
+	}
+	switch value[*pos] {
+	case '[':
+		*pos++
+		list := []interface{}{}
+		for {
+			skipYamlSpaces(value, pos)
+			if *pos < len(value) && ']' == value[*pos] {
+				*pos++
+				return list, nil
+			}
+			item, err := parseYamlFlow(value, pos)
+			if nil != err {
+				return nil, err
+			}
+			list = append(list, item)
+			if err := skipYamlFlowSeparator(value, pos, ']'); nil != err {
+				return nil, err
+			}
+		}
+	case '{':
+		*pos++
+		mapping := make(map[string]interface{})
+		for {
+			skipYamlSpaces(value, pos)
+			if *pos < len(value) && '}' == value[*pos] {
+				*pos++
+				return mapping, nil
+			}
+			key, err := parseYamlFlow(value, pos)
+			if nil != err {
+				return nil, err
+			}
+			skipYamlSpaces(value, pos)
+			if *pos >= len(value) || ':' != value[*pos] {
+				return nil, SourceError{Message: "Invalid yaml: expected ':' in flow mapping"}
+			}
+			*pos++
+			item, err := parseYamlFlow(value, pos)
+			if nil != err {
+				return nil, err
+			}
+			mapping[formatYamlKey(key)] = item
+			if err := skipYamlFlowSeparator(value, pos, '}'); nil != err {
+				return nil, err
+			}
+		}
+	case '"', '\'':
+		quote := value[*pos]
+		end := *pos + 1
+		for end < len(value) {
+			if quote == value[end] {
+				if '\'' == quote && end+1 < len(value) && '\'' == value[end+1] {
+					end += 2
+					continue
+				}
+				break
+			}
+			if '"' == quote && '\\' == value[end] {
+				end++
+			}
+			end++
+		}
+		if end >= len(value) {
+			return nil, SourceError{Message: "Invalid yaml: unclosed quote"}
+		}
+		text, err := parseYamlQuoted(value[*pos : end+1])
+		*pos = end + 1
+		return text, err
+	}
+	start := *pos
+	for *pos < len(value) && !strings.ContainsRune(",]}", rune(value[*pos])) {
+		// the colon of a flow mapping ends a plain key
+		if ':' == value[*pos] && (*pos+1 == len(value) || ' ' == value[*pos+1]) {
+			break
+		}
+		*pos++
+	}
+	plain := strings.TrimSpace(value[start:*pos])
+	if err := checkYamlPlain(plain); nil != err {
+		return nil, err
+	}
+	return parseYamlPlain(plain), nil
+}
+
+func skipYamlSpaces(value string, pos *int) {
+	for *pos < len(value) && ' ' == value[*pos] {
+		*pos++
+	}
+}
+
+func skipYamlFlowSeparator(value string, pos *int, closing byte) error {
+	skipYamlSpaces(value, pos)
+	if *pos >= len(value) {
+		return SourceError{Message: "Invalid yaml: missing '" + string(closing) + "'"}
+	}
+	if ',' == value[*pos] {
+		*pos++
+		return nil
+	}
+	if closing != value[*pos] {
+		return SourceError{Message: "Invalid yaml: expected ',' or '" + string(closing) + "' but got '" + value[*pos:] + "'"}
+	}
+	return nil
+}
+
+func parseYamlQuoted(value string) (string, error) {
+	if 2 > len(value) || value[0] != value[len(value)-1] {
+		return "", SourceError{Message: "Invalid yaml: unclosed quote in " + value}
+	}
+	if '\'' == value[0] {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	}
+	if '"' != value[0] {
+		return "", SourceError{Message: "Invalid yaml: " + value + " is not quoted"}
+	}
+	text, err := strconv.Unquote(value)
+	if nil != err {
+		return "", SourceError{Message: "Invalid yaml: invalid escape in " + value}
+	}
+	return text, nil
+}
+
+// checkYamlPlain rejects plain scalars starting with the indicators of the
+// unsupported anchors, aliases and tags instead of reading them as text
+func checkYamlPlain(value string) error {
+	switch {
+	case strings.HasPrefix(value, "&") || strings.HasPrefix(value, "*"):
+		return SourceError{Message: "Invalid yaml: anchors and aliases are not supported"}
+	case strings.HasPrefix(value, "!"):
+		return SourceError{Message: "Invalid yaml: tags are not supported"}
+	}
+	return nil
+}
+
+// parseYamlPlain types a plain scalar as null, bool, number or string
+func parseYamlPlain(value string) interface{} {
+	switch value {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if yamlNumberRxp.MatchString(value) {
+		if number, err := strconv.ParseFloat(value, 64); nil == err {
+			return number
+		}
+	}
+	return value
+}
+
+func formatYamlKey(key interface{}) string {
+	switch value := key.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return ""
+}
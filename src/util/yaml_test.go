@@ -0,0 +1,281 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYaml(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    interface{}
+	}{
+		{
+			name:    "empty",
+			content: "",
+			want:    nil,
+		},
+		{
+			name:    "scalars",
+			content: "title: Hello\ncount: 3\nratio: 0.5\npublished: true\nmissing: ~\n",
+			want: map[string]interface{}{
+				"title":     "Hello",
+				"count":     float64(3),
+				"ratio":     0.5,
+				"published": true,
+				"missing":   nil,
+			},
+		},
+		{
+			name:    "nested mappings",
+			content: "site:\n  author:\n    name: Jane\n    city: Berlin\n  lang: en\n",
+			want: map[string]interface{}{
+				"site": map[string]interface{}{
+					"author": map[string]interface{}{"name": "Jane", "city": "Berlin"},
+					"lang":   "en",
+				},
+			},
+		},
+		{
+			name:    "lists",
+			content: "tags:\n  - go\n  - yaml\nempty: []\n",
+			want: map[string]interface{}{
+				"tags":  []interface{}{"go", "yaml"},
+				"empty": []interface{}{},
+			},
+		},
+		{
+			name:    "list at key indentation",
+			content: "tags:\n- go\n- yaml\n",
+			want:    map[string]interface{}{"tags": []interface{}{"go", "yaml"}},
+		},
+		{
+			name:    "list of mappings",
+			content: "- name: Jane\n  role: lead\n- name: John\n  role: dev\n",
+			want: []interface{}{
+				map[string]interface{}{"name": "Jane", "role": "lead"},
+				map[string]interface{}{"name": "John", "role": "dev"},
+			},
+		},
+		{
+			name:    "nested lists",
+			content: "- - a\n  - b\n-\n  - c\n",
+			want: []interface{}{
+				[]interface{}{"a", "b"},
+				[]interface{}{"c"},
+			},
+		},
+		{
+			name:    "quoting",
+			content: "double: \"a: b # c\"\nsingle: 'it''s'\nescaped: \"line\\nbreak\"\nnumber: \"42\"\n\"quoted key\": x\n",
+			want: map[string]interface{}{
+				"double":     "a: b # c",
+				"single":     "it's",
+				"escaped":    "line\nbreak",
+				"number":     "42",
+				"quoted key": "x",
+			},
+		},
+		{
+			name:    "comments",
+			content: "# leading comment\n---\nname: Jane # trailing comment\n\n  # indented comment\nurl: http://domain.tld/#anchor\n",
+			want: map[string]interface{}{
+				"name": "Jane",
+				"url":  "http://domain.tld/#anchor",
+			},
+		},
+		{
+			name:    "flow collections",
+			content: "list: [a, 'b, c', 1]\nmap: {x: 1, y: [2, 3]}\n",
+			want: map[string]interface{}{
+				"list": []interface{}{"a", "b, c", float64(1)},
+				"map":  map[string]interface{}{"x": float64(1), "y": []interface{}{float64(2), float64(3)}},
+			},
+		},
+		{
+			name:    "quoted keys",
+			content: "'single key': 1\n\"a: b\": 2\n",
+			want: map[string]interface{}{
+				"single key": float64(1),
+				"a: b":       float64(2),
+			},
+		},
+		{
+			name:    "flow mappings",
+			content: "- {name: 'x', \"k y\": [1, {z: 2}]}\n- {}\n",
+			want: []interface{}{
+				map[string]interface{}{"name": "x", "k y": []interface{}{float64(1), map[string]interface{}{"z": float64(2)}}},
+				map[string]interface{}{},
+			},
+		},
+		{
+			name:    "yaml 1.2 core schema",
+			content: "a: yes\nb: null\nc: False\nd: 0x10\n",
+			want: map[string]interface{}{
+				"a": "yes",
+				"b": nil,
+				"c": false,
+				"d": "0x10",
+			},
+		},
+		{
+			name:    "literal block",
+			content: "text: |\n  first\n    indented\n\n  last\nnext: x\n",
+			want: map[string]interface{}{
+				"text": "first\n  indented\n\nlast\n",
+				"next": "x",
+			},
+		},
+		{
+			name:    "folded block",
+			content: "text: >\n  one\n  two\n\n  three\n",
+			want:    map[string]interface{}{"text": "one two\n\nthree\n"},
+		},
+		{
+			name:    "block chomping",
+			content: "strip: |-\n  a\nkeep: |+\n  b\n\nclip: |\n  c\n",
+			want: map[string]interface{}{
+				"strip": "a",
+				"keep":  "b\n\n",
+				"clip":  "c\n",
+			},
+		},
+		{
+			name:    "block in a list",
+			content: "- |\n  block\n  in list\n- b\n",
+			want:    []interface{}{"block\nin list\n", "b"},
+		},
+		{
+			name:    "multi-line plain value in a list",
+			content: "- >-\n  folded\n  item\n- plain\n",
+			want:    []interface{}{"folded item", "plain"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseYaml(test.content)
+			if nil != err {
+				t.Fatalf("unexpected error '%s'", err.Error())
+			}
+			if !reflect.DeepEqual(test.want, got) {
+				t.Errorf("expected %#v but got %#v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestParseYamlErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+		message string
+	}{
+		{
+			name:    "tab indentation",
+			content: "site:\n\tname: x\n",
+			line:    2,
+			message: "Invalid yaml: tabs can't be used for indentation",
+		},
+		{
+			name:    "anchor",
+			content: "base: &base\n  name: x\n",
+			line:    1,
+			message: "Invalid yaml: anchors and aliases are not supported",
+		},
+		{
+			name:    "alias",
+			content: "base:\n  name: x\nother: *base\n",
+			line:    3,
+			message: "Invalid yaml: anchors and aliases are not supported",
+		},
+		{
+			name:    "alias in a list",
+			content: "- a\n- *first\n",
+			line:    2,
+			message: "Invalid yaml: anchors and aliases are not supported",
+		},
+		{
+			name:    "alias in a flow collection",
+			content: "list: [a, *b]\n",
+			line:    1,
+			message: "Invalid yaml: anchors and aliases are not supported",
+		},
+		{
+			name:    "tag",
+			content: "date: !!timestamp 2024-01-01\n",
+			line:    1,
+			message: "Invalid yaml: tags are not supported",
+		},
+		{
+			name:    "duplicate key",
+			content: "name: a\nname: b\n",
+			line:    2,
+			message: "Invalid yaml: duplicate key 'name'",
+		},
+		{
+			name:    "unexpected indentation",
+			content: "name: a\n    city: b\n",
+			line:    2,
+			message: "Invalid yaml: unexpected indentation",
+		},
+		{
+			name:    "unclosed quote",
+			content: "name: \"a\n",
+			line:    1,
+			message: "Invalid yaml: unclosed quote in \"a",
+		},
+		{
+			name:    "unclosed flow collection",
+			content: "list: [a, b\n",
+			line:    1,
+			message: "Invalid yaml: missing ']'",
+		},
+		{
+			name:    "flow collection over several lines",
+			content: "list: [a,\n  b]\n",
+			line:    1,
+			message: "Invalid yaml: unexpected end of flow collection",
+		},
+		{
+			name:    "plain value over several lines",
+			content: "text: plain\n  continued\n",
+			line:    2,
+			message: "Invalid yaml: unexpected indentation",
+		},
+		{
+			name:    "quoted value over several lines",
+			content: "text: 'multi\n  line'\n",
+			line:    1,
+			message: "Invalid yaml: unclosed quote in 'multi",
+		},
+		{
+			name:    "block indentation indicator",
+			content: "text: |2\n   x\n",
+			line:    1,
+			message: "Invalid yaml: unsupported block scalar header '|2'",
+		},
+		{
+			name:    "second document",
+			content: "name: a\n---\nname: b\n",
+			line:    2,
+			message: "Invalid yaml: multiple documents are not supported",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseYaml(test.content)
+			if nil == err {
+				t.Fatalf("expected error '%s'", test.message)
+			}
+			sourceErr, ok := err.(SourceError)
+			if !ok {
+				t.Fatalf("expected a SourceError but got %T", err)
+			}
+			if test.message != sourceErr.Message || test.line != sourceErr.Line {
+				t.Errorf("expected '%s' in line %d but got '%s' in line %d", test.message, test.line, sourceErr.Message, sourceErr.Line)
+			}
+		})
+	}
+}